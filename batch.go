@@ -0,0 +1,159 @@
+package runlogger
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// BatchOption configures a BatchWriter.
+type BatchOption func(*BatchWriter)
+
+// BatchWriter collects the entries written to it and writes them to the
+// underlying writer in batches, e.g. for a writer that sends every write
+// in a request to an API. See NewBatchWriter.
+type BatchWriter struct {
+	w          io.Writer
+	maxEntries int
+	maxBytes   int
+	interval   time.Duration
+
+	mu      sync.Mutex
+	buf     []byte
+	entries int
+	err     error // from the last flush by the interval
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewBatchWriter returns a BatchWriter that writes the entries to w in
+// batches, as the output of a logger:
+//
+//	batches := runlogger.NewBatchWriter(w,
+//		runlogger.WithBatchEntries(100),
+//		runlogger.WithBatchBytes(256<<10), // stay under the size limit of a request
+//		runlogger.WithBatchInterval(5*time.Second),
+//	)
+//	defer batches.Close()
+//	log := runlogger.StructuredLogger(runlogger.WithOutput(batches))
+//
+// Without any options every entry is written right away. Close (or Close
+// of the logger) flushes the last batch. A batch that can't be written,
+// after the retries for transient errors, is dropped and the error is
+// returned from the Write or Flush that wrote it, the error of a flush by
+// the interval is returned from the next Flush.
+func NewBatchWriter(w io.Writer, opts ...BatchOption) *BatchWriter {
+	b := &BatchWriter{w: w, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.interval > 0 {
+		b.wg.Add(1)
+		go b.flushEvery(b.interval)
+	}
+	return b
+}
+
+// WithBatchEntries flushes the batch when it has n entries.
+func WithBatchEntries(n int) BatchOption {
+	return func(b *BatchWriter) {
+		b.maxEntries = n
+	}
+}
+
+// WithBatchBytes keeps the batches at most n bytes, a batch is flushed
+// before an entry that would make it bigger is added. An entry that is n
+// bytes or more by itself is written alone, right away.
+func WithBatchBytes(n int) BatchOption {
+	return func(b *BatchWriter) {
+		b.maxBytes = n
+	}
+}
+
+// WithBatchInterval flushes the batch every interval, so the entries are
+// written even when not much is logged.
+func WithBatchInterval(interval time.Duration) BatchOption {
+	return func(b *BatchWriter) {
+		b.interval = interval
+	}
+}
+
+func (b *BatchWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.entries > 0 && b.maxBytes > 0 && len(b.buf)+len(p) > b.maxBytes {
+		if err := b.flush(); err != nil {
+			return 0, err
+		}
+	}
+	b.buf = append(b.buf, p...)
+	b.entries++
+	if b.full() {
+		if err := b.flush(); err != nil {
+			return len(p), err // p was taken, so it's not written again
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the current batch.
+func (b *BatchWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.flush()
+	if err == nil {
+		err, b.err = b.err, nil
+	}
+	return err
+}
+
+// Close stops the flushing by the interval and flushes the last batch. The
+// underlying writer isn't closed.
+func (b *BatchWriter) Close() error {
+	b.once.Do(func() {
+		close(b.done)
+		b.wg.Wait()
+	})
+	return b.Flush()
+}
+
+// full tells if the batch should be flushed after an entry was added
+func (b *BatchWriter) full() bool {
+	if b.maxEntries == 0 && b.maxBytes == 0 && b.interval == 0 {
+		return true
+	}
+	return b.maxEntries > 0 && b.entries >= b.maxEntries || b.maxBytes > 0 && len(b.buf) >= b.maxBytes
+}
+
+func (b *BatchWriter) flush() error {
+	if b.entries == 0 {
+		return nil
+	}
+	err := writeRetrying(b.w, b.buf)
+	// a new buffer for the next batch, the writer might keep the one it was
+	// given (e.g. to send it later), and one big entry doesn't keep a big
+	// buffer around
+	b.buf = nil
+	b.entries = 0
+	return err
+}
+
+func (b *BatchWriter) flushEvery(interval time.Duration) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			if err := b.flush(); err != nil {
+				b.err = err
+			}
+			b.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package runlogger
+
+import (
+	"strings"
+	"testing"
+)
+
+// batchRecorder keeps the batches written to it without copying them, so
+// a BatchWriter reusing its buffer changes the earlier batches
+type batchRecorder struct {
+	batches [][]byte
+}
+
+func (r *batchRecorder) Write(p []byte) (int, error) {
+	r.batches = append(r.batches, p)
+	return len(p), nil
+}
+
+func (r *batchRecorder) strings() []string {
+	var batches []string
+	for _, batch := range r.batches {
+		batches = append(batches, string(batch))
+	}
+	return batches
+}
+
+func TestBatchBytes(t *testing.T) {
+	small1, small2, small3 := "a1234\n", "b1234\n", "e1234\n"
+	big := strings.Repeat("c", 20) + "\n"
+	tests := []struct {
+		name    string
+		writes  []string
+		batches []string
+	}{
+		{"fits", []string{small1, small2}, []string{small1 + small2}},
+		{"flushed before an entry that doesn't fit", []string{small1, small2, small3}, []string{small1 + small2, small3}},
+		{"entry alone over the threshold", []string{big}, []string{big}},
+		{"entry over the threshold after others", []string{small1, big, small2}, []string{small1, big, small2}},
+		{"entry at the threshold", []string{strings.Repeat("d", 15) + "\n"}, []string{strings.Repeat("d", 15) + "\n"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := &batchRecorder{}
+			b := NewBatchWriter(out, WithBatchBytes(16))
+			for _, write := range test.writes {
+				if n, err := b.Write([]byte(write)); n != len(write) || err != nil {
+					t.Fatalf("Write returned %d, %v", n, err)
+				}
+			}
+			if err := b.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(out.strings(), "|"); got != strings.Join(test.batches, "|") {
+				t.Errorf("expected the batches %q, got %q", test.batches, out.strings())
+			}
+		})
+	}
+}
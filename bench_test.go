@@ -0,0 +1,96 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+// benchmarkMarshaler logs a typical entry with the marshaler. To compare
+// with another encoder, add a benchmark that calls it with that
+// encoder's Marshal, e.g. jsoniter.ConfigCompatibleWithStandardLibrary.Marshal,
+// and run go test -bench Marshaler -benchmem.
+func benchmarkMarshaler(b *testing.B, marshal func(interface{}) ([]byte, error)) {
+	l := StructuredLogger(WithOutput(io.Discard), WithMarshaler(marshal))
+	user := struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Roles []string `json:"roles"`
+	}{42, "Ada", []string{"admin", "dev"}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("user logged in", l.Field("user", user), l.Field("attempt", i), l.Field("error", errors.New("none")))
+	}
+}
+
+func BenchmarkMarshalerStdlib(b *testing.B) {
+	benchmarkMarshaler(b, json.Marshal)
+}
+
+// benchmarkLoggers runs the benchmark with a structured and a plain logger,
+// run with go test -bench . -benchmem
+func benchmarkLoggers(b *testing.B, run func(b *testing.B, l *Logger)) {
+	for _, logger := range []struct {
+		name      string
+		newLogger func(...Option) *Logger
+	}{
+		{"Structured", StructuredLogger},
+		{"Plain", PlainLogger},
+	} {
+		b.Run(logger.name, func(b *testing.B) {
+			l := logger.newLogger(WithOutput(io.Discard))
+			b.ReportAllocs()
+			b.ResetTimer()
+			run(b, l)
+		})
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	benchmarkLoggers(b, func(b *testing.B, l *Logger) {
+		for i := 0; i < b.N; i++ {
+			l.Info("request handled")
+		}
+	})
+}
+
+func BenchmarkInfoOneField(b *testing.B) {
+	benchmarkLoggers(b, func(b *testing.B, l *Logger) {
+		for i := 0; i < b.N; i++ {
+			l.Info("request handled", l.Field("status", 200))
+		}
+	})
+}
+
+func BenchmarkInfoFiveFields(b *testing.B) {
+	benchmarkLoggers(b, func(b *testing.B, l *Logger) {
+		for i := 0; i < b.N; i++ {
+			l.Info("request handled",
+				l.Field("status", 200),
+				l.Field("method", "GET"),
+				l.Field("path", "/users/42"),
+				l.Field("bytes", 1234),
+				l.Field("cached", false),
+			)
+		}
+	})
+}
+
+func BenchmarkErrorf(b *testing.B) {
+	err := errors.New("connection refused")
+	benchmarkLoggers(b, func(b *testing.B, l *Logger) {
+		for i := 0; i < b.N; i++ {
+			l.Errorf("calling %s failed: %v", "users", err)
+		}
+	})
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	l := StructuredLogger(WithOutput(io.Discard), WithMinSeverity(ErrorSeverity))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debug("not logged")
+	}
+}
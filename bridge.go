@@ -0,0 +1,182 @@
+package runlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type bridge struct {
+	l *Logger
+
+	mu  sync.Mutex
+	buf []byte // the start of a line that hasn't ended yet
+}
+
+// NewBridge returns a writer that takes newline delimited JSON logs, e.g.
+// from the output of another process, and logs every line as an entry with
+// l, so it's written in the format of l:
+//
+//	cmd.Stdout = runlogger.NewBridge(log)
+//
+// The severity is taken from "severity" or "level", as text like "warn" or
+// "WARNING" or as a number (bunyan/pino levels or the LogSeverity enum),
+// the message from "message" or "msg" and the timestamp, as RFC 3339 text
+// or unix seconds or milliseconds, from "timestamp" or "time". The other
+// keys become fields. Lines that aren't JSON objects are logged as DEBUG
+// entries with the line as the message.
+//
+// A line is logged when its newline is written. Lines longer than 100 KiB
+// are logged in parts of at most 100 KiB, as DEBUG entries since the parts
+// aren't JSON, instead of being buffered until they end.
+func NewBridge(l *Logger) io.Writer {
+	return &bridge{l: l}
+}
+
+func (b *bridge) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	for {
+		var line []byte
+		if i := bytes.IndexByte(b.buf, '\n'); i >= 0 {
+			line, b.buf = b.buf[:i], b.buf[i+1:]
+		} else if len(b.buf) > maxSize {
+			// the start of a line that long is logged by itself, so the
+			// buffer doesn't grow without bounds
+			n := len(truncate(string(b.buf[:maxSize+1]), maxSize))
+			if n == 0 {
+				n = maxSize
+			}
+			line, b.buf = b.buf[:n], b.buf[n:]
+		} else {
+			break
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			b.l.emit(b.entry(line))
+		}
+	}
+	if len(b.buf) == 0 {
+		b.buf = nil // don't keep a big buffer around
+	}
+	return len(p), nil
+}
+
+func (b *bridge) entry(line []byte) *Entry {
+	now := b.l.conf().now()
+	values := map[string]interface{}{}
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&values); err != nil || d.More() {
+		return &Entry{Severity: DebugSeverity, Message: string(line), Timestamp: now}
+	}
+
+	entry := &Entry{Severity: DefaultSeverity, Timestamp: now}
+	for _, key := range []string{"severity", "level"} {
+		if value, ok := values[key]; ok {
+			entry.Severity = bridgeSeverity(value)
+			delete(values, key)
+			break
+		}
+	}
+	for _, key := range []string{"message", "msg"} {
+		if value, ok := values[key].(string); ok {
+			entry.Message = value
+			delete(values, key)
+			break
+		}
+	}
+	for _, key := range []string{"timestamp", "time"} {
+		if t, ok := bridgeTime(values[key]); ok {
+			entry.Timestamp = t
+			delete(values, key)
+			break
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry.Fields = append(entry.Fields, &Field{key, values[key]})
+	}
+	return entry
+}
+
+var bridgeSeverities = map[string]Severity{
+	"trace":     DebugSeverity,
+	"debug":     DebugSeverity,
+	"info":      InfoSeverity,
+	"notice":    NoticeSeverity,
+	"warn":      WarningSeverity,
+	"warning":   WarningSeverity,
+	"err":       ErrorSeverity,
+	"error":     ErrorSeverity,
+	"crit":      CriticalSeverity,
+	"critical":  CriticalSeverity,
+	"fatal":     CriticalSeverity,
+	"panic":     CriticalSeverity,
+	"dpanic":    CriticalSeverity,
+	"alert":     AlertSeverity,
+	"emerg":     EmergencySeverity,
+	"emergency": EmergencySeverity,
+}
+
+// bridgeSeverity takes the severity or level as text or as a number, which
+// is a bunyan/pino level (10 to 60) or a LogSeverity (100 to 800)
+func bridgeSeverity(v interface{}) Severity {
+	switch level := v.(type) {
+	case string:
+		if severity, ok := bridgeSeverities[strings.ToLower(level)]; ok {
+			return severity
+		}
+	case json.Number:
+		n, err := level.Int64()
+		switch {
+		case err != nil:
+		case n >= 100:
+			return decodeSeverity(level)
+		case n >= 60:
+			return CriticalSeverity
+		case n >= 50:
+			return ErrorSeverity
+		case n >= 40:
+			return WarningSeverity
+		case n >= 30:
+			return InfoSeverity
+		case n > 0:
+			return DebugSeverity
+		}
+	}
+	return DefaultSeverity
+}
+
+// bridgeTime takes the time as RFC 3339 text or as unix seconds or
+// milliseconds
+func bridgeTime(v interface{}) (time.Time, bool) {
+	switch value := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		return t, err == nil
+	case json.Number:
+		if n, err := value.Int64(); err == nil && n > 1e11 {
+			return time.Unix(n/1000, n%1000*1e6), true
+		}
+		f, err := value.Float64()
+		if err != nil || f <= 0 {
+			return time.Time{}, false
+		}
+		if f > 1e11 { // milliseconds, as seconds it would be after year 5000
+			f /= 1000
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+	}
+	return time.Time{}, false
+}
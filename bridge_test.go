@@ -0,0 +1,58 @@
+package runlogger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBridge(t *testing.T) {
+	l, buf := testLogger()
+	bridge := NewBridge(l)
+	bridge.Write([]byte(`{"level":"warn","msg":"disk almost full","time":1632737647,"disk":"/dev/sda"}` + "\n" + `not js`))
+	bridge.Write([]byte("on\n"))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got %q", lines)
+	}
+	for i, want := range [][]string{
+		{`"message":"disk almost full"`, `"severity":"WARNING"`, `"timestamp":"2021-09-27T10:14:07Z"`},
+		{`"message":"not json"`, `"severity":"DEBUG"`},
+	} {
+		for _, want := range want {
+			if !strings.Contains(lines[i], want) {
+				t.Errorf("expected %s in %s", want, lines[i])
+			}
+		}
+	}
+	if payload := decode(t, buf)[0]; payload["disk"] != "/dev/sda" {
+		t.Errorf("expected the disk field, got %v", payload)
+	}
+}
+
+func TestBridgeLongLineIsCapped(t *testing.T) {
+	var buf bytes.Buffer
+	var entries int
+	l, _ := testLogger(WithOutput(writerFunc(func(p []byte) (int, error) {
+		entries++
+		return buf.Write(p)
+	})))
+	bridge := NewBridge(l).(*bridge)
+	chunk := strings.Repeat("日本", 1000)
+	for i := 0; i < 100; i++ {
+		bridge.Write([]byte(chunk))
+		if len(bridge.buf) > maxSize {
+			t.Fatalf("expected at most %d bytes buffered, got %d", maxSize, len(bridge.buf))
+		}
+	}
+	bridge.Write([]byte("\n"))
+
+	total := 100 * len(chunk)
+	if want := (total + maxSize - 1) / maxSize; entries != want {
+		t.Errorf("expected %d entries, got %d", want, entries)
+	}
+	if strings.Contains(buf.String(), "\\ufffd") {
+		t.Error("expected the parts to be split between runes")
+	}
+}
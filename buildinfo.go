@@ -0,0 +1,19 @@
+//go:build go1.18
+
+package runlogger
+
+import "runtime/debug"
+
+// addVCS adds the vcs settings that Go 1.18 and newer build into binaries
+func (info *buildInfo) addVCS(bi *debug.BuildInfo) {
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !go1.18
+
+package runlogger
+
+import "runtime/debug"
+
+// addVCS does nothing, the vcs settings aren't in the build info before
+// Go 1.18
+func (info *buildInfo) addVCS(bi *debug.BuildInfo) {}
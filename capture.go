@@ -0,0 +1,31 @@
+package runlogger
+
+import "sync"
+
+// maxCaptured is how many entries Capture keeps
+const maxCaptured = 1000
+
+// Capture returns a copy of the logger that, besides writing the entries
+// as usual, keeps them so they can be retrieved with get. E.g. to return
+// all the logs of a request from a debug endpoint. Only the last 1000
+// entries are kept.
+func (l *Logger) Capture() (child *Logger, get func() []*Entry) {
+	var (
+		mu      sync.Mutex
+		entries []*Entry
+	)
+	child = l.withTap(func(entry *Entry) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(entries) == maxCaptured {
+			entries = append(entries[:0], entries[1:]...)
+		}
+		entries = append(entries, entry)
+	})
+	get = func() []*Entry {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*Entry{}, entries...)
+	}
+	return child, get
+}
@@ -0,0 +1,197 @@
+package runlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type change struct {
+	Name    string                 `json:"name"`
+	Action  string                 `json:"action"`
+	Added   map[string]interface{} `json:"added,omitempty"`
+	Removed map[string]interface{} `json:"removed,omitempty"`
+	Changed map[string]valueChange `json:"changed,omitempty"`
+}
+
+type valueChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// LogChange logs the difference between old and new as a NOTICE with a
+// structured "change" field. Maps are compared by key and structs by their
+// exported fields, other values are compared as a whole. A nil old value is
+// logged as a creation and a nil new value as a deletion.
+func (l *Logger) LogChange(name string, old, new interface{}, fields ...*Field) {
+	c := diff(name, old, new)
+	l.writeLog(NoticeSeverity, fmt.Sprintf("%s %s", name, c.Action), append(fields[:len(fields):len(fields)], l.Field("change", c)))
+}
+
+// LogEnvDiff logs the environment variables that were added, removed or
+// changed from before to after like LogChange, e.g. with the variables read
+// before and after a config change. The values of the variables with a
+// name that has a word like SECRET, PASSWORD, TOKEN or KEY in it (see
+// WithRedactedWords) are logged as "[REDACTED]", so only the change of
+// them is seen.
+func (l *Logger) LogEnvDiff(before, after map[string]string, fields ...*Field) {
+	c := diff("environment", before, after)
+	conf := l.conf()
+	for _, values := range []map[string]interface{}{c.Added, c.Removed} {
+		for key := range values {
+			if conf.redacts(key) {
+				values[key] = "[REDACTED]"
+			}
+		}
+	}
+	for key := range c.Changed {
+		if conf.redacts(key) {
+			c.Changed[key] = valueChange{"[REDACTED]", "[REDACTED]"}
+		}
+	}
+	l.writeLog(NoticeSeverity, fmt.Sprintf("%s %s", c.Name, c.Action), append(fields[:len(fields):len(fields)], l.Field("change", c)))
+}
+
+// Tracker logs the changes of a value over time, see Track.
+type Tracker struct {
+	l      *Logger
+	name   string
+	fields []*Field
+
+	mu   sync.Mutex
+	last map[string]interface{} // a deep copy, see snapshot
+}
+
+// Track returns a Tracker for the value, which starts out as initial. Its
+// Update logs the changes like LogChange, but only when there are any, e.g.
+// for polling loops that mostly see the same state:
+//
+//	t := log.Track("session", session)
+//	for range ticker.C {
+//		t.Update(fetchSession())
+//	}
+//
+// The fields are added to every entry.
+func (l *Logger) Track(name string, initial interface{}, fields ...*Field) *Tracker {
+	return &Tracker{l: l, name: name, fields: fields, last: snapshot(initial)}
+}
+
+// Update logs the changes since the last value, if there are any.
+func (t *Tracker) Update(value interface{}) {
+	values := snapshot(value)
+	t.mu.Lock()
+	c := diff(t.name, t.last, values)
+	t.last = values
+	t.mu.Unlock()
+	if c.Action == "unchanged" {
+		return
+	}
+	t.l.writeLog(NoticeSeverity, fmt.Sprintf("%s %s", t.name, c.Action), append(append([]*Field{}, t.fields...), &Field{"change", c}))
+}
+
+func diff(name string, old, new interface{}) *change {
+	c := &change{Name: name}
+	oldValues, newValues := flatten(old), flatten(new)
+	switch {
+	case oldValues == nil && newValues == nil:
+		c.Action = "unchanged"
+		return c
+	case oldValues == nil:
+		c.Action = "created"
+		c.Added = newValues
+		return c
+	case newValues == nil:
+		c.Action = "deleted"
+		c.Removed = oldValues
+		return c
+	}
+
+	for key, oldValue := range oldValues {
+		newValue, ok := newValues[key]
+		if !ok {
+			if c.Removed == nil {
+				c.Removed = map[string]interface{}{}
+			}
+			c.Removed[key] = oldValue
+		} else if !reflect.DeepEqual(oldValue, newValue) {
+			if c.Changed == nil {
+				c.Changed = map[string]valueChange{}
+			}
+			c.Changed[key] = valueChange{oldValue, newValue}
+		}
+	}
+	for key, newValue := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			if c.Added == nil {
+				c.Added = map[string]interface{}{}
+			}
+			c.Added[key] = newValue
+		}
+	}
+
+	if c.Added == nil && c.Removed == nil && c.Changed == nil {
+		c.Action = "unchanged"
+	} else {
+		c.Action = "updated"
+	}
+	return c
+}
+
+// flatten turns maps and structs into a map of their top level values, any
+// other value is put under the "value" key. nil (also typed nil) gives nil.
+func flatten(v interface{}) map[string]interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	values := map[string]interface{}{}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			values[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+		}
+	case reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" { // unexported
+				continue
+			}
+			values[t.Field(i).Name] = rv.Field(i).Interface()
+		}
+	default:
+		values["value"] = rv.Interface()
+	}
+	return values
+}
+
+// snapshot flattens v like flatten, with the values copied as their JSON,
+// so changing the maps and slices of v in place later doesn't change the
+// snapshot. Values that can't be marshaled are kept as they are.
+func snapshot(v interface{}) map[string]interface{} {
+	values := flatten(v)
+	for key, value := range values {
+		j, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		d := json.NewDecoder(bytes.NewReader(j))
+		d.UseNumber() // so the numbers are logged as they were
+		var copied interface{}
+		if d.Decode(&copied) == nil {
+			values[key] = copied
+		}
+	}
+	return values
+}
@@ -0,0 +1,40 @@
+package runlogger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrackerSeesChangesInPlace(t *testing.T) {
+	l, buf := testLogger()
+	session := map[string]interface{}{
+		"user":  "u1",
+		"roles": map[string]bool{"admin": false},
+		"tags":  []string{"a"},
+	}
+	tracker := l.Track("session", session)
+
+	tracker.Update(session)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no entry for an unchanged value, got %s", buf)
+	}
+
+	session["roles"].(map[string]bool)["admin"] = true
+	tracker.Update(session)
+	if !strings.Contains(buf.String(), `"roles":{"old":{"admin":false},"new":{"admin":true}}`) {
+		t.Errorf("expected the change of the nested map, got %s", buf)
+	}
+
+	buf.Reset()
+	session["tags"].([]string)[0] = "b"
+	tracker.Update(session)
+	if !strings.Contains(buf.String(), `"tags":{"old":["a"],"new":["b"]}`) {
+		t.Errorf("expected the change of the nested slice, got %s", buf)
+	}
+
+	buf.Reset()
+	tracker.Update(session)
+	if buf.Len() != 0 {
+		t.Errorf("expected no entry after the changes were logged, got %s", buf)
+	}
+}
@@ -0,0 +1,49 @@
+package runlogger
+
+// WithCloseSummary makes Close log a NOTICE with the number of entries
+// written per severity (see Stats) and the time since the logger was
+// created, e.g. as a last line of a batch job.
+func WithCloseSummary() Option {
+	return func(c *config) {
+		c.closeSummary = true
+	}
+}
+
+// Close flushes the histograms of the logger (see Histogram), logs the
+// summary if the logger has WithCloseSummary and flushes the output if it
+// has a Flush method. The output isn't closed. Only the first
+// Close of a logger, or the loggers derived from it, logs the summary.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.state.histogramsMu.Lock()
+	histograms := l.state.histograms
+	l.state.histogramsMu.Unlock()
+	for _, h := range histograms {
+		h.Flush()
+	}
+
+	c := l.conf()
+	first := false
+	l.state.closeOnce.Do(func() { first = true })
+	if c.closeSummary && first {
+		counts := map[Severity]uint64{}
+		for severity, count := range l.Stats() {
+			if count > 0 {
+				counts[severity] = count
+			}
+		}
+		l.writeLog(NoticeSeverity, "summary", []*Field{
+			{"counts", counts},
+			l.Duration("uptime", c.now().Sub(l.state.started)),
+		})
+	}
+
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+	if f, ok := l.state.out.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package runlogger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// LogConfig logs v, typically the configuration of the application, as a
+// NOTICE with a structured "config" field, e.g. at start up. Structs are
+// logged with their exported fields, named like encoding/json does, and
+// pointers, maps and slices are followed. Secrets are kept out of the log
+// with a struct tag:
+//
+//	type Config struct {
+//		Port     int
+//		Password string `log:"mask"` // logged as "[REDACTED]" if it's set
+//		Token    string `log:"-"`    // left out
+//	}
+func (l *Logger) LogConfig(v interface{}, fields ...*Field) {
+	value := configValue(reflect.ValueOf(v), map[uintptr]bool{})
+	l.writeLog(NoticeSeverity, "config", append(fields[:len(fields):len(fields)], &Field{"config", value}))
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// configValue turns v into something that marshals like v without the
+// fields that are left out or masked, seen has the pointers on the way to v
+func configValue(v reflect.Value, seen map[uintptr]bool) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface() // like time.Time, they know how to marshal themselves
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Ptr {
+			if seen[v.Pointer()] {
+				return "[cycle]"
+			}
+			seen[v.Pointer()] = true
+			defer delete(seen, v.Pointer())
+		}
+		return configValue(v.Elem(), seen)
+	case reflect.Struct:
+		values := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" { // unexported
+				continue
+			}
+			tag := field.Tag.Get("log")
+			if tag == "-" {
+				continue
+			}
+			name := field.Name
+			if jsonName := strings.Split(field.Tag.Get("json"), ",")[0]; jsonName == "-" {
+				continue
+			} else if jsonName != "" {
+				name = jsonName
+			}
+			if tag == "mask" {
+				if v.Field(i).IsZero() {
+					values[name] = v.Field(i).Interface()
+				} else {
+					values[name] = "[REDACTED]"
+				}
+				continue
+			}
+			values[name] = configValue(v.Field(i), seen)
+		}
+		return values
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		values := map[string]interface{}{}
+		iter := v.MapRange()
+		for iter.Next() {
+			values[fmt.Sprint(iter.Key().Interface())] = configValue(iter.Value(), seen)
+		}
+		return values
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = configValue(v.Index(i), seen)
+		}
+		return values
+	}
+	return v.Interface()
+}
+
+// InfoTagged logs an INFO with the exported fields of the struct v as
+// fields, named by their log tag or else by their name. The fields of
+// embedded structs are logged like the fields of v, other embedded types
+// under their type name. Fields are left out and masked like with
+// LogConfig, and fields with a name that has a redacted word in it (see
+// WithRedactedWords) are masked too:
+//
+//	type Signup struct {
+//		Email    string `log:"email"`
+//		Plan     string `log:"plan"`
+//		Password string `log:"-"`
+//		Phone    string `log:"phone,mask"` // or `log:"mask"` to keep the name
+//	}
+//
+//	log.InfoTagged("signup", signup)
+func (l *Logger) InfoTagged(msg string, v interface{}) {
+	l.writeLog(InfoSeverity, msg, taggedFields(reflect.ValueOf(v), l.conf()))
+}
+
+func taggedFields(v reflect.Value, c *config) []*Field {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		if !v.IsValid() {
+			return nil
+		}
+		return []*Field{{"value", configValue(v, map[uintptr]bool{})}}
+	}
+
+	var fields []*Field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("log")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name, mask := parts[0], false
+		for _, option := range parts[1:] {
+			mask = mask || option == "mask"
+		}
+		if name == "mask" && len(parts) == 1 {
+			name, mask = "", true
+		}
+		embedded := field.Type
+		if embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && embedded.Kind() == reflect.Struct && name == "" && !mask {
+			fields = append(fields, taggedFields(v.Field(i), c)...)
+			continue
+		}
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		mask = mask || c.redacts(name) || c.redacts(field.Name)
+		switch {
+		case mask && v.Field(i).IsZero():
+			fields = append(fields, &Field{name, v.Field(i).Interface()})
+		case mask:
+			fields = append(fields, &Field{name, "[REDACTED]"})
+		default:
+			fields = append(fields, &Field{name, configValue(v.Field(i), map[uintptr]bool{})})
+		}
+	}
+	return fields
+}
@@ -0,0 +1,107 @@
+package runlogger
+
+import (
+	"context"
+	"fmt"
+)
+
+type contextKey struct {
+	key  interface{}
+	name string
+}
+
+// WithContextKeys makes WithContext add the values of the keys in the
+// context as fields, named after the key as text (fmt.Sprint). Keys that
+// aren't in the context are left out. Use WithNamedContextKey for keys that
+// don't have a good text form, like struct{} types.
+func WithContextKeys(keys ...interface{}) Option {
+	return func(c *config) {
+		for _, key := range keys {
+			c.contextKeys = append(c.contextKeys, contextKey{key, fmt.Sprint(key)})
+		}
+	}
+}
+
+// WithNamedContextKey is like WithContextKeys for a single key, with the
+// field named name.
+func WithNamedContextKey(name string, key interface{}) Option {
+	return func(c *config) {
+		c.contextKeys = append(c.contextKeys, contextKey{key, name})
+	}
+}
+
+// WithContext returns a copy of the logger with the values of the context
+// keys (see WithContextKeys) added as fields to every entry:
+//
+//	log.WithContext(r.Context()).Info("order placed")
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	child := l.clone()
+	var fields []*Field
+	for _, key := range child.conf().contextKeys {
+		if value := ctx.Value(key.key); value != nil {
+			fields = append(fields, &Field{key.name, value})
+		}
+	}
+	if len(fields) > 0 {
+		child.fields = append(append([]*Field{}, child.fields...), fields...)
+	}
+	if child.conf().contextErrors != nil || child.early {
+		child.ctx = ctx // an early logger gets the fields when it's derived again, see SetDefault
+	}
+	return child
+}
+
+// WithContextErrors makes the loggers from WithContext add a
+// "context_error" field to the entries logged after the context is done,
+// e.g. "context deadline exceeded", to find work that is done after the
+// request was canceled. With escalate the entries below WARNING are logged
+// as WARNING then.
+func WithContextErrors(escalate bool) Option {
+	return func(c *config) {
+		c.contextErrors = &escalate
+	}
+}
+
+// addContextError adds the error of the logger's context to the entry if
+// it's done
+func (l *Logger) addContextError(entry *Entry, escalate bool) {
+	err := l.ctx.Err()
+	if err == nil {
+		return
+	}
+	entry.Fields = append(entry.Fields, &Field{"context_error", err.Error()})
+	if escalate && entry.Severity.Number() < WarningSeverity.Number() {
+		entry.Severity = WarningSeverity
+	}
+}
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx that carries l, for FromContext. The
+// Middleware does this with the logger bound to the trace of the request.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx (see NewContext), or the
+// package default logger set with SetDefault if it doesn't carry one.
+// Before SetDefault is called it returns nil, the plain logger that writes
+// to stderr, and not the logger Default returns then: that one keeps the
+// entries until SetDefault is called, so they would be lost in
+// applications that never call it.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := loggerFromContext(ctx); ok {
+		return l
+	}
+	early.Lock()
+	defer early.Unlock()
+	if !early.set {
+		return nil
+	}
+	return early.logger
+}
+
+func loggerFromContext(ctx context.Context) (*Logger, bool) {
+	l, ok := ctx.Value(loggerKey{}).(*Logger)
+	return l, ok
+}
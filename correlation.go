@@ -0,0 +1,38 @@
+package runlogger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// WithNewCorrelationID returns a copy of the logger with a new random id in
+// a "correlation_id" field on every entry, and the id, e.g. to tie together
+// the entries of a request that doesn't have a trace:
+//
+//	log, id := log.WithNewCorrelationID()
+//	w.Header().Set("X-Correlation-Id", id)
+func (l *Logger) WithNewCorrelationID() (*Logger, string) {
+	newID := l.conf().newCorrelationID
+	if newID == nil {
+		newID = randomID
+	}
+	id := newID()
+	child := l.clone()
+	child.fields = append(append([]*Field{}, child.fields...), &Field{"correlation_id", id})
+	return child, id
+}
+
+// WithCorrelationIDs makes WithNewCorrelationID get the ids from newID,
+// e.g. to make them predictable in tests.
+func WithCorrelationIDs(newID func() string) Option {
+	return func(c *config) {
+		c.newCorrelationID = newID
+	}
+}
+
+// randomID returns 16 random bytes as hex
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
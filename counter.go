@@ -0,0 +1,20 @@
+package runlogger
+
+type counterValue struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+// Count logs an INFO that the counter name went up by n, in a "counter"
+// field:
+//
+//	log.Count("payment_failed", 1, log.Field("provider", provider))
+//
+// gives {"counter": {"name": "payment_failed", "value": 1}, "provider": "..."}.
+// A log-based metric in Cloud Monitoring that filters on
+// jsonPayload.counter.name and sums jsonPayload.counter.value then counts
+// the events, e.g. to alert on a threshold.
+func (l *Logger) Count(name string, n int64, fields ...*Field) {
+	fields = append([]*Field{{"counter", counterValue{name, n}}}, fields...)
+	l.writeLog(InfoSeverity, "counter "+name, fields)
+}
@@ -0,0 +1,148 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DatadogFormatter formats entries as JSON for the Datadog log intake, with
+// the reserved attributes Datadog reads the status, service, host, tags and
+// the trace from:
+//
+//	{"date":"2021-09-27T10:14:07.123Z","status":"info","message":"user logged in","service":"app","host":"h1","ddtags":"env:prod","dd":{"trace_id":"4611686018427387904","span_id":"1"},"user":"u1"}
+//
+// The fields are at the top level, a field with the name of a reserved
+// attribute gets an underscore before and after it, e.g. "_status_". The
+// labels are the tags, the httpRequest is logged in the "http" and
+// "network" attributes and the source location in "logger".
+//
+// The trace and span the logger is bound to (see WithTrace) are logged in
+// the decimal form Datadog uses to connect the logs to the traces, the
+// lower 64 bits of a 128 bit trace id. Ids that aren't hex are logged as
+// they are.
+type DatadogFormatter struct {
+	service  string
+	hostname string
+}
+
+// NewDatadogFormatter returns a DatadogFormatter that logs service as the
+// service, K_SERVICE is used when it's empty.
+func NewDatadogFormatter(service string) *DatadogFormatter {
+	if service == "" {
+		service = os.Getenv("K_SERVICE")
+	}
+	hostname, _ := os.Hostname()
+	return &DatadogFormatter{service: service, hostname: hostname}
+}
+
+var datadogStatuses = map[Severity]string{
+	DefaultSeverity:   "info",
+	DebugSeverity:     "debug",
+	InfoSeverity:      "info",
+	NoticeSeverity:    "notice",
+	WarningSeverity:   "warning",
+	ErrorSeverity:     "error",
+	CriticalSeverity:  "critical",
+	AlertSeverity:     "alert",
+	EmergencySeverity: "emergency",
+}
+
+var datadogReservedKeys = map[string]bool{
+	"date":    true,
+	"status":  true,
+	"message": true,
+	"service": true,
+	"host":    true,
+	"ddtags":  true,
+	"dd":      true,
+	"http":    true,
+	"network": true,
+	"logger":  true,
+}
+
+type datadogTrace struct {
+	TraceID string `json:"trace_id,omitempty"`
+	SpanID  string `json:"span_id,omitempty"`
+}
+
+type datadogHTTP struct {
+	Method     string `json:"method,omitempty"`
+	URL        string `json:"url,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	UserAgent  string `json:"useragent,omitempty"`
+	Referer    string `json:"referer,omitempty"`
+}
+
+type datadogLogger struct {
+	FileName   string `json:"file_name"`
+	Line       int    `json:"line"`
+	MethodName string `json:"method_name,omitempty"`
+}
+
+func (f *DatadogFormatter) Format(entry *Entry) ([]byte, error) {
+	record := map[string]interface{}{}
+	for _, field := range entry.Fields {
+		key := field.Key
+		if datadogReservedKeys[key] {
+			key = "_" + key + "_"
+		}
+		record[key] = field.Value
+	}
+	for key, value := range entry.TopLevel {
+		if !datadogReservedKeys[key] {
+			record[key] = value
+		}
+	}
+
+	status, ok := datadogStatuses[entry.Severity]
+	if !ok {
+		status = "info"
+	}
+	record["date"] = entry.Timestamp.UTC().Format(time.RFC3339Nano)
+	record["status"] = status
+	record["message"] = entry.Message
+	if f.service != "" {
+		record["service"] = f.service
+	}
+	if f.hostname != "" {
+		record["host"] = f.hostname
+	}
+	if len(entry.Labels) > 0 {
+		tags := make([]string, 0, len(entry.Labels))
+		for key, value := range entry.Labels {
+			tags = append(tags, key+":"+value)
+		}
+		sort.Strings(tags)
+		record["ddtags"] = strings.Join(tags, ",")
+	}
+	if entry.Trace != "" || entry.SpanID != "" {
+		trace := entry.Trace[strings.LastIndex(entry.Trace, "/")+1:] // without projects/<project>/traces/
+		record["dd"] = datadogTrace{datadogID(trace), datadogID(entry.SpanID)}
+	}
+	if r := entry.HTTPRequest; r != nil {
+		record["http"] = datadogHTTP{r.RequestMethod, r.RequestURL, r.Status, r.UserAgent, r.Referer}
+		if r.RemoteIP != "" {
+			record["network"] = map[string]interface{}{"client": map[string]string{"ip": r.RemoteIP}}
+		}
+	}
+	if entry.File != "" {
+		record["logger"] = datadogLogger{entry.File, entry.Line, entry.Function}
+	}
+	return json.Marshal(record)
+}
+
+// datadogID turns a hex id into the decimal of its lower 64 bits
+func datadogID(id string) string {
+	hex := id
+	if len(hex) > 16 {
+		hex = hex[len(hex)-16:]
+	}
+	if n, err := strconv.ParseUint(hex, 16, 64); err == nil {
+		return strconv.FormatUint(n, 10)
+	}
+	return id
+}
@@ -0,0 +1,78 @@
+package runlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// DecodeEntry parses a line written by a StructuredLogger back into an
+// Entry. The fields are sorted by key, and numbers are decoded as
+// json.Number so large integers keep their precision.
+func DecodeEntry(data []byte) (*Entry, error) {
+	return NewDecoder(bytes.NewReader(data)).Decode()
+}
+
+// Decoder reads the entries written by a StructuredLogger from a stream.
+type Decoder struct {
+	decoder *json.Decoder
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return &Decoder{decoder}
+}
+
+// Decode returns the next entry, or io.EOF when there are no more.
+func (d *Decoder) Decode() (*Entry, error) {
+	var payload stackdriverLogStruct
+	if err := d.decoder.Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		Severity:  decodeSeverity(payload.Severity),
+		Message:   payload.Message,
+		Timestamp: payload.Timestamp,
+		Trace:     payload.Trace,
+		SpanID:    payload.SpanID,
+		Labels:    payload.Labels,
+
+		HTTPRequest: payload.HTTPRequest,
+	}
+	if payload.SourceLocation != nil {
+		entry.File = payload.SourceLocation.File
+		entry.Function = payload.SourceLocation.Function
+		entry.Line, _ = strconv.Atoi(payload.SourceLocation.Line)
+	}
+
+	keys := make([]string, 0, len(payload.JsonPayload))
+	for key := range payload.JsonPayload {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry.Fields = append(entry.Fields, &Field{key, payload.JsonPayload[key]})
+	}
+	return entry, nil
+}
+
+// decodeSeverity takes the severity both as text and as a number
+func decodeSeverity(v interface{}) Severity {
+	switch severity := v.(type) {
+	case string:
+		return Severity(severity)
+	case json.Number:
+		n, _ := severity.Int64()
+		for _, s := range severities {
+			if int64(s.Number()) == n {
+				return s
+			}
+		}
+	}
+	return DefaultSeverity
+}
@@ -0,0 +1,104 @@
+package runlogger
+
+import "sync"
+
+// maxEarlyEntries is how many entries are kept until SetDefault is called
+const maxEarlyEntries = 1000
+
+// earlyEntry is an entry logged before SetDefault and the logger it was
+// logged with, derived from Default
+type earlyEntry struct {
+	entry *Entry
+	from  *Logger
+}
+
+var early = struct {
+	sync.Mutex
+	logger  *Logger      // what Default returns
+	entries []earlyEntry // logged before SetDefault
+	dropped int
+	set     bool
+}{
+	logger: &Logger{early: true, state: defaultState},
+}
+
+// Default returns the logger set with SetDefault. Before SetDefault is
+// called it returns a logger that keeps the entries and writes them with
+// the logger given to SetDefault, so packages can log from init functions
+// before the application has configured logging. Loggers returned before
+// SetDefault keep forwarding to the default after it has been set. The
+// loggers derived from them (like with WithTrace, Once or Capture) write
+// with the default logger derived the same way.
+//
+// Only the first 1000 entries are kept if SetDefault is never called.
+func Default() *Logger {
+	early.Lock()
+	defer early.Unlock()
+	return early.logger
+}
+
+// SetDefault makes l the logger returned by Default, and writes the
+// entries logged with Default before this with l. Giving it the logger
+// Default returns before SetDefault has been called does nothing, since
+// that logger only forwards to the default.
+func SetDefault(l *Logger) {
+	if l != nil && l.early {
+		return
+	}
+	early.Lock()
+	early.logger = l
+	early.set = true
+	entries, dropped := early.entries, early.dropped
+	early.entries = nil
+	early.dropped = 0
+	early.Unlock()
+
+	// written without the lock, a hook or an output might log with Default
+	for _, e := range entries {
+		e.entry.File = relative(e.entry.File) // the prefix path might not have been set when it was logged
+		e.from.derive(l).emit(e.entry)
+	}
+	if dropped > 0 {
+		l.Warningf("dropped %d entries logged before runlogger.SetDefault was called", dropped)
+	}
+}
+
+// emitEarly keeps the entry logged with from until SetDefault is called,
+// and writes it with the default logger after that
+func emitEarly(from *Logger, entry *Entry) {
+	early.Lock()
+	if !early.set {
+		if len(early.entries) < maxEarlyEntries {
+			early.entries = append(early.entries, earlyEntry{entry, from})
+		} else {
+			early.dropped++
+		}
+		early.Unlock()
+		return
+	}
+	l := early.logger
+	early.Unlock()
+	from.derive(l).emit(entry)
+}
+
+// derive returns l derived like the early logger e was derived from
+// Default: with its trace, taps, once key, fields and context
+func (e *Logger) derive(l *Logger) *Logger {
+	child := l.clone()
+	if e.trace != "" {
+		child.trace, child.spanID = e.trace, e.spanID
+	}
+	if len(e.taps) > 0 {
+		child.taps = append(append([]func(*Entry){}, child.taps...), e.taps...)
+	}
+	if e.onceKey != "" {
+		child.onceKey = e.onceKey
+	}
+	if len(e.fields) > 0 {
+		child.fields = append(append([]*Field{}, child.fields...), e.fields...)
+	}
+	if e.ctx != nil {
+		child = child.WithContext(e.ctx) // the context keys are the ones of l
+	}
+	return child
+}
@@ -0,0 +1,98 @@
+package runlogger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func resetDefault() {
+	early.Lock()
+	early.logger = &Logger{early: true, state: defaultState}
+	early.entries, early.dropped, early.set = nil, 0, false
+	early.Unlock()
+}
+
+func TestSetDefaultWithHookThatLogs(t *testing.T) {
+	resetDefault()
+	defer resetDefault()
+
+	Default().Info("before")
+	logged := false
+	l, buf := testLogger(WithHook(func(entry *Entry) (*Entry, bool) {
+		if !logged {
+			logged = true
+			Default().Info("from hook") // would deadlock if SetDefault held the lock
+		}
+		return entry, true
+	}))
+	SetDefault(l)
+	SetDefault(Default())
+	if got := buf.String(); !strings.Contains(got, "before") || !strings.Contains(got, "from hook") {
+		t.Errorf("expected both entries, got %s", got)
+	}
+}
+
+func TestSetDefaultWithEarlyLogger(t *testing.T) {
+	resetDefault()
+	defer resetDefault()
+
+	SetDefault(Default()) // must not make Default forward to itself
+	Default().Info("kept")
+	l, buf := testLogger()
+	SetDefault(l)
+	if got := buf.String(); !strings.Contains(got, "kept") {
+		t.Errorf("expected the early entry, got %s", got)
+	}
+}
+
+func TestFromContextBeforeSetDefault(t *testing.T) {
+	resetDefault()
+	defer resetDefault()
+
+	if l := FromContext(context.Background()); l != nil {
+		t.Errorf("expected the plain logger before SetDefault, got %+v", l)
+	}
+	l, _ := testLogger()
+	SetDefault(l)
+	if got := FromContext(context.Background()); got != l {
+		t.Errorf("expected the default logger after SetDefault, got %+v", got)
+	}
+	child, _ := testLogger()
+	if got := FromContext(NewContext(context.Background(), child)); got != child {
+		t.Errorf("expected the logger of the context, got %+v", got)
+	}
+}
+
+func TestDerivedFromDefaultBeforeSetDefault(t *testing.T) {
+	resetDefault()
+	defer resetDefault()
+
+	type userKey struct{}
+	ctx := context.WithValue(context.Background(), userKey{}, "u1")
+	Default().WithTrace("projects/p/traces/abc", "1").Info("traced")
+	once := Default().Once(t.Name())
+	once.Info("once")
+	once.Info("once")
+	captured, get := Default().Capture()
+	captured.Info("captured")
+	Default().WithContext(ctx).Info("with context")
+
+	l, buf := testLogger(WithNamedContextKey("user", userKey{}))
+	SetDefault(l)
+	captured.Info("captured after")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 entries, got %q", lines)
+	}
+	if !strings.Contains(lines[0], `"logging.googleapis.com/trace":"projects/p/traces/abc"`) {
+		t.Errorf("expected the trace, got %s", lines[0])
+	}
+	if !strings.Contains(lines[3], `"user":"u1"`) {
+		t.Errorf("expected the context field, got %s", lines[3])
+	}
+	if entries := get(); len(entries) != 2 || entries[0].Message != "captured" || entries[1].Message != "captured after" {
+		t.Errorf("expected both captured entries, got %d", len(entries))
+	}
+}
@@ -0,0 +1,46 @@
+package runlogger
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type deprecationValue struct {
+	What       string `json:"what"`
+	UseInstead string `json:"use_instead,omitempty"`
+	RemovedIn  string `json:"removed_in,omitempty"`
+}
+
+// Deprecated logs a WARNING that what is deprecated, with a "deprecation"
+// field, for use in the deprecated function itself:
+//
+//	func OldFetch() {
+//		log.Deprecated("OldFetch", "Fetch", "v2.0.0")
+//		...
+//	}
+//
+// The source location is where the deprecated function was called from,
+// and it's only logged the first time for each such location, so it
+// doesn't fill the logs when called in a loop.
+func (l *Logger) Deprecated(what, useInstead, removeInVersion string) {
+	pc, file, line, _ := runtime.Caller(2)
+	if !firstTime(fmt.Sprintf("deprecated %s %s:%d", what, file, line)) {
+		return
+	}
+	message := what + " is deprecated"
+	if useInstead != "" {
+		message += ", use " + useInstead + " instead"
+	}
+	entry := &Entry{
+		Severity:  WarningSeverity,
+		Message:   message,
+		Fields:    []*Field{{"deprecation", deprecationValue{what, useInstead, removeInVersion}}},
+		Timestamp: l.conf().now(),
+	}
+	if l.conf().withSourceLocation(WarningSeverity) {
+		entry.File = relative(file)
+		entry.Line = line
+		entry.Function = runtime.FuncForPC(pc).Name()
+	}
+	l.emit(entry)
+}
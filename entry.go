@@ -0,0 +1,62 @@
+package runlogger
+
+import (
+	"runtime"
+	"time"
+)
+
+// Entry is a log entry as it's assembled before being written. This is
+// what hooks get to inspect and change.
+type Entry struct {
+	Severity  Severity
+	Message   string
+	Fields    []*Field
+	Timestamp time.Time
+	File      string // relative to the directory the logger was created in
+	Line      int
+	Function  string
+	Trace     string
+	SpanID    string
+	Labels    map[string]string
+
+	HTTPRequest *HTTPRequest
+
+	TopLevel map[string]interface{} // keys at the root of the LogEntry, see TopLevel
+
+	Callers []Frame // the call stack from the log call, see WithCallerFrames
+}
+
+// Frame is a function in the call stack.
+type Frame struct {
+	File     string
+	Line     int
+	Function string
+}
+
+// extractHTTPRequest moves an HTTPRequest field out of the entry's fields
+func extractHTTPRequest(entry *Entry) {
+	for i, field := range entry.Fields {
+		if value, ok := field.Value.(httpRequestValue); ok {
+			entry.HTTPRequest = value.HTTPRequest
+			entry.Fields = append(append([]*Field{}, entry.Fields[:i]...), entry.Fields[i+1:]...)
+			return
+		}
+	}
+}
+
+// callers returns up to n frames of the call stack, skipping skip frames
+// like runtime.Callers
+func callers(skip, n int) []Frame {
+	pcs := make([]uintptr, n)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip+1, pcs)])
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			stack = append(stack, Frame{relative(frame.File), frame.Line, frame.Function})
+		}
+		if !more {
+			return stack
+		}
+	}
+}
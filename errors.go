@@ -0,0 +1,98 @@
+package runlogger
+
+import (
+	"errors"
+	"fmt"
+)
+
+type causeValue struct {
+	Error string      `json:"error"`
+	Type  string      `json:"type"`
+	Code  interface{} `json:"code,omitempty"`
+}
+
+// ErrorCause logs msg as an ERROR with the error in a structured "cause"
+// field, so the message stays readable while the cause can be grouped on.
+// The cause has the type of the innermost error and, if an error in the
+// chain has a Code() method returning a string or an int, its code.
+func (l *Logger) ErrorCause(msg string, cause error, fields ...*Field) {
+	l.writeLog(ErrorSeverity, msg, append(append(errorFields(cause), fields...), l.cause(cause)))
+}
+
+func (l *Logger) cause(err error) *Field {
+	if err == nil {
+		return &Field{"cause", nil}
+	}
+	root := err
+	for unwrapped := errors.Unwrap(root); unwrapped != nil; unwrapped = errors.Unwrap(root) {
+		root = unwrapped
+	}
+	value := causeValue{
+		Error: err.Error(),
+		Type:  fmt.Sprintf("%T", root),
+	}
+	var (
+		stringCoder interface{ Code() string }
+		intCoder    interface{ Code() int }
+	)
+	if errors.As(err, &stringCoder) {
+		value.Code = stringCoder.Code()
+	} else if errors.As(err, &intCoder) {
+		value.Code = intCoder.Code()
+	}
+	return &Field{"cause", value}
+}
+
+// Result logs the outcome of an operation, an INFO if err is nil and an
+// ERROR with the error otherwise, both with an "operation" field:
+//
+//	user, err := fetchUser(id)
+//	log.Result("fetchUser", err, log.Field("user_id", id))
+//
+// Add a Duration field for the time it took, or use Timed.
+func (l *Logger) Result(operation string, err error, fields ...*Field) {
+	fields = append([]*Field{{"operation", operation}}, fields...)
+	if err == nil {
+		l.writeLog(InfoSeverity, operation+" succeeded", fields)
+		return
+	}
+	fields = append(append(fields, errorFields(err)...), &Field{"error", err.Error()})
+	l.writeLog(ErrorSeverity, operation+" failed: "+err.Error(), fields)
+}
+
+type fieldsError struct {
+	err    error
+	fields []*Field
+}
+
+func (e *fieldsError) Error() string { return e.err.Error() }
+func (e *fieldsError) Unwrap() error { return e.err }
+
+// WithFields returns err with fields attached, without changing its
+// message. When the error is logged (as an argument to a log method, or
+// with ErrorCause or Result) the fields are logged too, so each layer can
+// add what it knows while the error is returned:
+//
+//	if err != nil {
+//		return runlogger.WithFields(err, log.Field("order_id", id))
+//	}
+//
+// errors.Is and errors.As see through it. A nil err gives nil.
+func WithFields(err error, fields ...*Field) error {
+	if err == nil {
+		return nil
+	}
+	return &fieldsError{err, fields}
+}
+
+// errorFields returns the fields attached to err with WithFields, the ones
+// attached last (furthest out) come last
+func errorFields(err error) []*Field {
+	var fields []*Field
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*fieldsError); ok {
+			fields = append(append([]*Field{}, e.fields...), fields...)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,183 @@
+package runlogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Deadline returns a "deadline" field with the time left until the context's
+// deadline (e.g. "1.5s"), negative if it has already passed, or
+// "no deadline" if the context doesn't have one. The time left is measured
+// from the clock of the logger, see WithClock.
+func (l *Logger) Deadline(ctx context.Context) *Field {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return &Field{"deadline", "no deadline"}
+	}
+	return &Field{"deadline", deadline.Sub(l.conf().now()).String()}
+}
+
+type bytesValue struct {
+	Data      []byte `json:"data"` // base64 encoded by json.Marshal
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Bytes returns a field with b base64 encoded, cut to the WithMaxBytes
+// limit. The field also has the size of b and tells if it was truncated.
+func (l *Logger) Bytes(key string, b []byte) *Field {
+	value := bytesValue{Data: b, Size: len(b)}
+	if max := l.conf().maxBytes; len(b) > max {
+		value.Data = b[:max]
+		value.Truncated = true
+	}
+	return &Field{key, value}
+}
+
+type bodyValue struct {
+	Text      string `json:"text,omitempty"`
+	Base64    []byte `json:"base64,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Body reads up to limit bytes from r and returns them as a field, as text
+// if it's valid UTF-8 and base64 encoded if it's not. The field tells if the
+// body was longer than limit.
+//
+// Since the bytes are read from r, Body also returns a reader that gives the
+// whole body, the part that was read followed by the rest of r. Use it
+// instead of r afterwards. Closing it closes r if r is an io.Closer, so it
+// can be put back in place of an http.Response.Body:
+//
+//	field, resp.Body = log.Body("body", resp.Body, 1024)
+//
+// With a limit of 0 or less nothing is read, the field is empty and r is
+// returned as it is.
+func (l *Logger) Body(key string, r io.Reader, limit int) (*Field, io.ReadCloser) {
+	if limit <= 0 {
+		rc, ok := r.(io.ReadCloser)
+		if !ok {
+			rc = io.NopCloser(r)
+		}
+		return &Field{key, bodyValue{}}, rc
+	}
+	buf := make([]byte, limit+1) // one extra byte to know if there is more
+	n, err := io.ReadFull(r, buf)
+	buf = buf[:n]
+
+	var value bodyValue
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		value.Error = err.Error()
+	}
+	logged := buf
+	if len(logged) > limit {
+		logged = logged[:limit]
+		value.Truncated = true
+	}
+	// truncate the whole buffer, it has the byte after a cut rune
+	if text := truncate(string(buf), limit); utf8.ValidString(text) {
+		value.Text = text
+	} else {
+		value.Base64 = logged
+	}
+
+	rest := &bodyReader{io.MultiReader(bytes.NewReader(buf), r), r}
+	return &Field{key, value}, rest
+}
+
+type bodyReader struct {
+	io.Reader
+	original io.Reader
+}
+
+func (b *bodyReader) Close() error {
+	if closer, ok := b.original.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+type statusValue struct {
+	Code int    `json:"code"`
+	Name string `json:"name"`
+}
+
+// Status returns a "status" field with the HTTP status code and its name,
+// e.g. {"code":404,"name":"Not Found"}.
+func (l *Logger) Status(code int) *Field {
+	return &Field{"status", statusValue{code, http.StatusText(code)}}
+}
+
+var grpcCodeNames = []string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// GRPCStatus returns a "status" field with the gRPC status code and its
+// canonical name, e.g. {"code":5,"name":"NOT_FOUND"}.
+func (l *Logger) GRPCStatus(code int) *Field {
+	name := ""
+	if code >= 0 && code < len(grpcCodeNames) {
+		name = grpcCodeNames[code]
+	}
+	return &Field{"status", statusValue{code, name}}
+}
+
+// Integer returns a field that is written as a JSON integer with all its
+// digits, also for a float64 holding a large integer (like an id decoded
+// from JSON into an interface{}) which would otherwise be written in
+// exponent notation. Values that aren't integers are logged as is.
+//
+// Note that a float64 only holds integers up to 2^53 exactly, to keep the
+// precision of larger ones when decoding JSON use json.Decoder.UseNumber,
+// json.Number values are written unchanged.
+func (l *Logger) Integer(key string, v interface{}) *Field {
+	switch n := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return &Field{key, json.Number(fmt.Sprint(n))}
+	case float64:
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return &Field{key, json.Number(strconv.FormatFloat(n, 'f', 0, 64))}
+		}
+	case float32:
+		if f := float64(n); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return &Field{key, json.Number(strconv.FormatFloat(f, 'f', 0, 32))}
+		}
+	}
+	return &Field{key, v}
+}
+
+type durationValue struct {
+	String string `json:"string"`
+	Nanos  int64  `json:"nanos"`
+}
+
+// Duration returns a field with d both as text for people and as
+// nanoseconds for dashboards, e.g. {"string":"1.2s","nanos":1200000000}.
+func (l *Logger) Duration(key string, d time.Duration) *Field {
+	return &Field{key, durationValue{d.String(), d.Nanoseconds()}}
+}
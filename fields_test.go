@@ -0,0 +1,195 @@
+package runlogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLogger returns a structured logger that writes to the returned buffer
+// with a fixed clock and without source locations
+func testLogger(opts ...Option) (*Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	now := time.Date(2021, 9, 27, 10, 14, 7, 0, time.UTC)
+	opts = append([]Option{
+		WithOutput(&buf),
+		WithClock(func() time.Time { return now }),
+		WithSourceLocationFor(),
+	}, opts...)
+	return StructuredLogger(opts...), &buf
+}
+
+// decode returns the jsonPayload of every line in buf
+func decode(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var payloads []map[string]interface{}
+	for _, line := range bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n")) {
+		var entry struct {
+			JsonPayload map[string]interface{} `json:"jsonPayload"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("invalid JSON %q: %v", line, err)
+		}
+		payloads = append(payloads, entry.JsonPayload)
+	}
+	return payloads
+}
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int
+		data     []byte
+		want     map[string]interface{}
+	}{
+		{"fits", 4, []byte{1, 2, 3}, map[string]interface{}{"data": "AQID", "size": 3.0}},
+		{"at the limit", 3, []byte{1, 2, 3}, map[string]interface{}{"data": "AQID", "size": 3.0}},
+		{"truncated", 2, []byte{1, 2, 3}, map[string]interface{}{"data": "AQI=", "size": 3.0, "truncated": true}},
+		{"negative limit", -1, []byte{1, 2, 3}, map[string]interface{}{"data": "", "size": 3.0, "truncated": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := testLogger(WithMaxBytes(tt.maxBytes))
+			l.Info("blob", l.Bytes("blob", tt.data))
+			got := decode(t, buf)[0]["blob"]
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if !bytes.Equal(gotJSON, wantJSON) {
+				t.Errorf("got %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestByteSliceFieldIsBase64(t *testing.T) {
+	l, buf := testLogger()
+	l.Info("blob", l.Field("raw", []byte{1, 2, 3}))
+	if got := decode(t, buf)[0]["raw"]; got != "AQID" {
+		t.Errorf("got %v, want AQID", got)
+	}
+}
+
+func TestBodyWithoutLimit(t *testing.T) {
+	for _, limit := range []int{0, -1, -2} {
+		l, _ := testLogger()
+		r := io.NopCloser(strings.NewReader("body"))
+		field, rest := l.Body("body", r, limit)
+		if rest != r {
+			t.Errorf("limit %d: expected the reader to be returned as it is", limit)
+		}
+		if !reflect.DeepEqual(field.Value, bodyValue{}) {
+			t.Errorf("limit %d: expected an empty field, got %+v", limit, field.Value)
+		}
+	}
+}
+
+// rawPayload returns the jsonPayload of the first line in buf as it was
+// written
+func rawPayload(t *testing.T, buf *bytes.Buffer) map[string]json.RawMessage {
+	t.Helper()
+	var entry struct {
+		JsonPayload map[string]json.RawMessage `json:"jsonPayload"`
+	}
+	line, _ := buf.ReadBytes('\n')
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatalf("invalid JSON %q: %v", line, err)
+	}
+	return entry.JsonPayload
+}
+
+func TestIntegerBeyond2To53(t *testing.T) {
+	var decoded map[string]interface{}
+	json.Unmarshal([]byte(`{"id": 1234567890123456789}`), &decoded)
+	useNumber := json.NewDecoder(strings.NewReader(`{"id": 1234567890123456789}`))
+	useNumber.UseNumber()
+	var numbers map[string]interface{}
+	useNumber.Decode(&numbers)
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"int64", int64(1234567890123456789), "1234567890123456789"},
+		{"uint64", uint64(18446744073709551615), "18446744073709551615"},
+		{"2^53+1", int64(1<<53 + 1), "9007199254740993"},
+		{"float64 from JSON", decoded["id"], "1234567890123456768"}, // the exact value of the float64
+		{"json.Number", numbers["id"], "1234567890123456789"},
+		{"not an integer", 1.5, "1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := testLogger()
+			l.Info("id", l.Integer("id", tt.value))
+			if got := string(rawPayload(t, buf)["id"]); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{1200 * time.Millisecond, `{"string":"1.2s","nanos":1200000000}`},
+		{1500 * time.Microsecond, `{"string":"1.5ms","nanos":1500000}`},
+		{250 * time.Nanosecond, `{"string":"250ns","nanos":250}`},
+		{0, `{"string":"0s","nanos":0}`},
+		{26*time.Hour + 3*time.Minute + 4*time.Second, `{"string":"26h3m4s","nanos":93784000000000}`},
+		{-90 * time.Minute, `{"string":"-1h30m0s","nanos":-5400000000000}`},
+	}
+	for _, tt := range tests {
+		l, buf := testLogger()
+		l.Info("took", l.Duration("elapsed", tt.d))
+		if got := string(rawPayload(t, buf)["elapsed"]); got != tt.want {
+			t.Errorf("Duration(%d) = %s, want %s", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFieldsArgumentIsNotAppendedTo(t *testing.T) {
+	l, _ := testLogger()
+	for name, log := range map[string]func(fields ...*Field){
+		"LogChange":      func(fields ...*Field) { l.LogChange("x", 1, 2, fields...) },
+		"LogEnvDiff":     func(fields ...*Field) { l.LogEnvDiff(nil, map[string]string{"A": "1"}, fields...) },
+		"LogConfig":      func(fields ...*Field) { l.LogConfig(struct{ A int }{1}, fields...) },
+		"ErrorCause":     func(fields ...*Field) { l.ErrorCause("failed", errors.New("x"), fields...) },
+		"Result":         func(fields ...*Field) { l.Result("op", errors.New("x"), fields...) },
+		"FlagEvaluation": func(fields ...*Field) { l.FlagEvaluation("flag", true, "default", fields...) },
+		"Published":      func(fields ...*Field) { l.Published("topic", "1", fields...) },
+		"Received":       func(fields ...*Field) { l.Received("topic", "1", fields...) },
+		"RuntimeStats":   func(fields ...*Field) { l.RuntimeStats(fields...) },
+		"Span":           func(fields ...*Field) { l.Span("op", time.Time{}, time.Time{}, fields...) },
+		"ValidationErrors": func(fields ...*Field) {
+			l.ValidationErrors(map[string]string{"a": "required"}, fields...)
+		},
+	} {
+		spare := &Field{"spare", nil}
+		backing := []*Field{l.Field("a", 1), spare}
+		log(backing[:1]...)
+		if backing[1] != spare {
+			t.Errorf("%s appended to the fields given to it: %v", name, backing[1])
+		}
+	}
+}
+
+func TestDeadlineUsesClock(t *testing.T) {
+	l, _ := testLogger()
+	deadline := time.Date(2021, 9, 27, 10, 14, 8, 500e6, time.UTC)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if got := l.Deadline(ctx).Value; got != "1.5s" {
+		t.Errorf("expected the time left from the clock of the logger, got %v", got)
+	}
+	if got := l.Deadline(context.Background()).Value; got != "no deadline" {
+		t.Errorf("expected no deadline, got %v", got)
+	}
+}
@@ -0,0 +1,23 @@
+package runlogger
+
+type flagValue struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+// FlagEvaluation logs a DEBUG with what a feature flag evaluated to and why
+// in a "flag" field:
+//
+//	log.FlagEvaluation("new-checkout", true, "user in beta group")
+//
+// gives {"flag": {"name": "new-checkout", "value": true, "reason": "user in beta group"}}.
+// It does nothing when DEBUG isn't enabled (see WithMinSeverity), so it's
+// cheap to call for every evaluation.
+func (l *Logger) FlagEvaluation(flag string, value interface{}, reason string, fields ...*Field) {
+	if !l.Enabled(DebugSeverity) {
+		return
+	}
+	fields = append(fields[:len(fields):len(fields)], &Field{"flag", flagValue{flag, value, reason}})
+	l.writeLog(DebugSeverity, "flag "+flag+" evaluated", fields)
+}
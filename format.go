@@ -0,0 +1,163 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Formatter turns an entry into the line that is written, without the
+// trailing newline.
+type Formatter interface {
+	Format(entry *Entry) ([]byte, error)
+}
+
+// WithFormatter makes the logger write entries in another format than the
+// default, which is the Stackdriver LogEntry format for StructuredLogger and
+// text for PlainLogger.
+func WithFormatter(formatter Formatter) Option {
+	return func(c *config) {
+		c.formatter = formatter
+	}
+}
+
+// BunyanFormatter formats entries as bunyan (https://github.com/trentm/node-bunyan)
+// records, with the fields at the top level next to the core bunyan fields.
+type BunyanFormatter struct {
+	name     string
+	hostname string
+	pid      int
+}
+
+// NewBunyanFormatter returns a BunyanFormatter that logs name as the name
+// of the application.
+func NewBunyanFormatter(name string) *BunyanFormatter {
+	hostname, _ := os.Hostname()
+	return &BunyanFormatter{
+		name:     name,
+		hostname: hostname,
+		pid:      os.Getpid(),
+	}
+}
+
+var bunyanLevels = map[Severity]int{
+	DefaultSeverity:   30,
+	DebugSeverity:     20,
+	InfoSeverity:      30,
+	NoticeSeverity:    30,
+	WarningSeverity:   40,
+	ErrorSeverity:     50,
+	CriticalSeverity:  60,
+	AlertSeverity:     60,
+	EmergencySeverity: 60,
+}
+
+var bunyanCoreKeys = map[string]bool{
+	"v":        true,
+	"level":    true,
+	"name":     true,
+	"hostname": true,
+	"pid":      true,
+	"time":     true,
+	"msg":      true,
+	"src":      true,
+}
+
+type bunyanSource struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Func string `json:"func,omitempty"`
+}
+
+func (f *BunyanFormatter) Format(entry *Entry) ([]byte, error) {
+	record := map[string]interface{}{}
+	for _, field := range entry.Fields {
+		key := field.Key
+		if bunyanCoreKeys[key] {
+			key = "_" + key + "_"
+		}
+		record[key] = field.Value
+	}
+	for key, value := range entry.Labels {
+		if _, ok := record[key]; !ok && !bunyanCoreKeys[key] {
+			record[key] = value
+		}
+	}
+
+	level, ok := bunyanLevels[entry.Severity]
+	if !ok {
+		level = 30
+	}
+	record["v"] = 0
+	record["level"] = level
+	record["name"] = f.name
+	record["hostname"] = f.hostname
+	record["pid"] = f.pid
+	record["time"] = entry.Timestamp.UTC().Format(time.RFC3339Nano)
+	record["msg"] = entry.Message
+	if entry.File != "" {
+		record["src"] = bunyanSource{entry.File, entry.Line, entry.Function}
+	}
+	return json.Marshal(record)
+}
+
+// TextFormatter formats entries as text, like the PlainLogger does.
+type TextFormatter struct{}
+
+func (TextFormatter) Format(entry *Entry) ([]byte, error) {
+	return formatPlain(entry), nil
+}
+
+type sink struct {
+	formatter Formatter // nil means the Cloud Logging format
+
+	mu  sync.Mutex // guards out
+	out io.Writer
+}
+
+// WithSink makes the logger also write every entry to out, formatted with
+// formatter, besides writing it to the output as usual. A nil formatter
+// formats the entries for Cloud Logging, like the StructuredLogger. It can
+// be given several times, e.g. to keep the JSON on stdout while also
+// writing text to a local file:
+//
+//	log := runlogger.StructuredLogger(
+//		runlogger.WithOutput(os.Stdout),
+//		runlogger.WithSink(runlogger.TextFormatter{}, file),
+//	)
+//
+// Writes that are short or fail with a transient error (like EINTR) are
+// retried a few times, like for the output. An error from a sink goes to
+// the error handler (see WithErrorHandler) and doesn't keep the entry from
+// the output or the other sinks.
+func WithSink(formatter Formatter, out io.Writer) Option {
+	return func(c *config) {
+		c.sinks = append(c.sinks, &sink{formatter: formatter, out: out})
+	}
+}
+
+// writeSinks writes the entry to the sinks of the logger
+func (l *Logger) writeSinks(entry *Entry) {
+	for _, s := range l.conf().sinks {
+		var (
+			j   []byte
+			err error
+		)
+		if s.formatter != nil {
+			j, err = s.formatter.Format(entry)
+		} else {
+			j, err = l.formatStackdriver(entry)
+		}
+		if err == nil {
+			s.mu.Lock()
+			err = writeRetrying(s.out, append(j, '\n'))
+			s.mu.Unlock()
+		}
+		if err != nil {
+			l.conf().handleError(fmt.Errorf("runlogger: writing an entry to a sink failed: %w", err))
+		}
+	}
+}
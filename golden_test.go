@@ -0,0 +1,45 @@
+package runlogger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func logGolden(l *Logger) {
+	l = l.WithTrace("projects/p/traces/abc", "0000000000000001")
+	l.Warning("golden entry",
+		l.Field("zeta", map[string]interface{}{"b": 2, "a": []int{3, 1, 2}, "c": map[string]string{"y": "1", "x": "2"}}),
+		l.Field("alpha", "first"),
+		l.Field("message", "reserved"),
+		l.Label("env", "prod"),
+		l.Label("region", "eu"),
+		l.Duration("elapsed", 1500000000),
+		l.HTTPRequest(&HTTPRequest{RequestMethod: "GET", RequestURL: "/x", Status: 200}),
+	)
+}
+
+const golden = `{"message":"golden entry","jsonPayload":{"_message_":"reserved","alpha":"first","elapsed":{"string":"1.5s","nanos":1500000000},"zeta":{"a":[3,1,2],"b":2,"c":{"x":"2","y":"1"}}},"severity":"WARNING","timestamp":"2021-09-27T10:14:07Z","logging.googleapis.com/trace":"projects/p/traces/abc","logging.googleapis.com/spanId":"0000000000000001","logging.googleapis.com/labels":{"env":"prod","region":"eu"},"httpRequest":{"requestMethod":"GET","requestUrl":"/x","status":200}}
+`
+
+func TestGoldenOutput(t *testing.T) {
+	for i := 0; i < 20; i++ { // map iteration order changes between runs
+		l, buf := testLogger()
+		logGolden(l)
+		if got := buf.String(); got != golden {
+			t.Fatalf("output changed:\ngot  %s\nwant %s", got, golden)
+		}
+	}
+}
+
+func TestGoldenOutputPlain(t *testing.T) {
+	var first []byte
+	for i := 0; i < 20; i++ {
+		var buf bytes.Buffer
+		logGolden(PlainLogger(WithOutput(&buf), WithSourceLocationFor()))
+		if first == nil {
+			first = buf.Bytes()
+		} else if !bytes.Equal(buf.Bytes(), first) {
+			t.Fatalf("output changed:\ngot  %s\nwant %s", buf.Bytes(), first)
+		}
+	}
+}
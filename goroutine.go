@@ -0,0 +1,63 @@
+package runlogger
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+var goroutineLoggers sync.Map // goroutine id -> *Logger
+
+// Bind makes l the logger Current returns in the calling goroutine, until
+// the returned function is called. It's for code where passing a logger or
+// a context around isn't practical, prefer that when possible since:
+//
+//   - the unbind function has to be called (defer it) before the goroutine
+//     ends, else the logger is kept forever and a later goroutine that gets
+//     the same id would get it
+//   - goroutines started after Bind don't get the logger, they have to
+//     Bind it themselves
+//   - finding the id of the goroutine costs a call to runtime.Stack, for
+//     every Bind and Current
+//
+// E.g. in a handler:
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//		defer log.WithTrace(trace, span).Bind()()
+//		doWork() // can use runlogger.Current()
+//	}
+func (l *Logger) Bind() (unbind func()) {
+	id := goroutineID()
+	previous, hadPrevious := goroutineLoggers.Load(id)
+	goroutineLoggers.Store(id, l)
+	return func() {
+		if hadPrevious {
+			goroutineLoggers.Store(id, previous)
+		} else {
+			goroutineLoggers.Delete(id)
+		}
+	}
+}
+
+// Current returns the logger bound to the calling goroutine with Bind, or
+// Default if there is none.
+func Current() *Logger {
+	if l, ok := goroutineLoggers.Load(goroutineID()); ok {
+		return l.(*Logger)
+	}
+	return Default()
+}
+
+// goroutineID parses the id from the "goroutine 123 [running]:" header of
+// the stack trace, the runtime doesn't expose it otherwise
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
@@ -0,0 +1,16 @@
+module github.com/karl-gustav/runlogger/grpclogger
+
+go 1.25.0
+
+require (
+	github.com/karl-gustav/runlogger v0.0.0-20261014192117-2a1e5d961ba2
+	google.golang.org/grpc v1.84.0
+)
+
+require (
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+)
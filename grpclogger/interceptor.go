@@ -0,0 +1,121 @@
+// Package grpclogger contains gRPC server interceptors that log every call
+// with runlogger. It lives in its own module so that the runlogger package
+// itself stays free of dependencies.
+package grpclogger
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karl-gustav/runlogger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type rpcInfo struct {
+	Method  string `json:"method"`
+	Code    string `json:"code"`
+	Latency string `json:"latency"`
+	Peer    string `json:"peer,omitempty"`
+}
+
+// UnaryServerInterceptor logs every unary call and makes a logger bound to
+// the call's trace available to the handler through FromContext.
+func UnaryServerInterceptor(log *runlogger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		l := traceLogger(ctx, log)
+		resp, err := handler(runlogger.NewContext(ctx, l), req)
+		logCall(ctx, l, info.FullMethod, err, start)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs every streaming call when it completes and
+// makes a logger bound to the call's trace available through FromContext.
+func StreamServerInterceptor(log *runlogger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := ss.Context()
+		l := traceLogger(ctx, log)
+		err := handler(srv, &serverStream{ss, runlogger.NewContext(ctx, l)})
+		logCall(ctx, l, info.FullMethod, err, start)
+		return err
+	}
+}
+
+// FromContext returns the logger injected by the interceptors, it's the
+// same as runlogger.FromContext. Without one it returns the logger set
+// with runlogger.SetDefault, or nil (the plain logger) if it hasn't been
+// set.
+func FromContext(ctx context.Context) *runlogger.Logger {
+	return runlogger.FromContext(ctx)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func logCall(ctx context.Context, l *runlogger.Logger, method string, err error, start time.Time) {
+	code := status.Code(err)
+	info := rpcInfo{
+		Method:  method,
+		Code:    code.String(),
+		Latency: time.Since(start).String(),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.Peer = p.Addr.String()
+	}
+	logFunc(l, code)(method, code.String(), l.Field("grpc", info))
+}
+
+// logFunc maps the gRPC status code to the severity it should be logged at
+func logFunc(l *runlogger.Logger, code codes.Code) func(v ...interface{}) {
+	switch code {
+	case codes.OK:
+		return l.Info
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.OutOfRange, codes.Aborted, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return l.Warning
+	default: // Unknown, Unimplemented, Internal, Unavailable, DataLoss
+		return l.Error
+	}
+}
+
+// traceLogger binds the logger to the trace in the incoming metadata, it
+// understands both the X-Cloud-Trace-Context header and W3C traceparent.
+func traceLogger(ctx context.Context, l *runlogger.Logger) *runlogger.Logger {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return l
+	}
+	if v := md.Get("x-cloud-trace-context"); len(v) > 0 {
+		// format: TRACE_ID/SPAN_ID;o=TRACE_TRUE
+		trace, rest, _ := strings.Cut(v[0], "/")
+		span, _, _ := strings.Cut(rest, ";")
+		if id, err := strconv.ParseUint(span, 10, 64); err == nil {
+			span = strconv.FormatUint(id, 16)
+			span = strings.Repeat("0", 16-len(span)) + span
+		}
+		return l.WithTrace(trace, span)
+	}
+	if v := md.Get("traceparent"); len(v) > 0 {
+		// format: VERSION-TRACE_ID-SPAN_ID-FLAGS
+		parts := strings.Split(v[0], "-")
+		if len(parts) == 4 {
+			return l.WithTrace(parts[1], parts[2])
+		}
+	}
+	return l
+}
@@ -0,0 +1,56 @@
+package grpclogger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karl-gustav/runlogger"
+	"github.com/karl-gustav/runlogger/runloggertest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptorSeverity(t *testing.T) {
+	tests := []struct {
+		err  error
+		want runlogger.Severity
+	}{
+		{nil, runlogger.InfoSeverity},
+		{status.Error(codes.NotFound, "no user"), runlogger.WarningSeverity},
+		{status.Error(codes.Internal, "broken"), runlogger.ErrorSeverity},
+	}
+	for _, tt := range tests {
+		t.Run(status.Code(tt.err).String(), func(t *testing.T) {
+			rec := runloggertest.NewRecorder(false)
+			interceptor := UnaryServerInterceptor(runlogger.StructuredLogger(rec.Option()))
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"))
+			info := &grpc.UnaryServerInfo{FullMethod: "/users.Users/Get"}
+			interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, tt.err
+			})
+
+			entries := rec.Entries()
+			if len(entries) != 1 {
+				t.Fatalf("expected one entry, got %d", len(entries))
+			}
+			entry := entries[0]
+			if entry.Severity != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, entry.Severity)
+			}
+			if entry.Trace != "0af7651916cd43dd8448eb211c80319c" || entry.SpanID != "b7ad6b7169203331" {
+				t.Errorf("expected the trace of the traceparent, got %q and %q", entry.Trace, entry.SpanID)
+			}
+			var got rpcInfo
+			for _, field := range entry.Fields {
+				if field.Key == "grpc" {
+					got, _ = field.Value.(rpcInfo)
+				}
+			}
+			if got.Method != info.FullMethod || got.Code != status.Code(tt.err).String() {
+				t.Errorf("expected the grpc field with the method and code, got %+v", got)
+			}
+		})
+	}
+}
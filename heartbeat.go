@@ -0,0 +1,62 @@
+package runlogger
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// StartHeartbeat logs an INFO "heartbeat" every interval, with the time
+// since the logger was created in an "uptime" field, until stop is called,
+// so monitoring can tell an idle worker from a stuck one:
+//
+//	stop := log.StartHeartbeat(time.Minute)
+//	defer stop()
+//
+// stop waits for the goroutine logging the heartbeats to end, and can be
+// called more than once. The source location is where StartHeartbeat was
+// called.
+func (l *Logger) StartHeartbeat(interval time.Duration, fields ...*Field) (stop func()) {
+	pc, file, line, _ := runtime.Caller(1)
+	started := defaultState.started
+	if l != nil {
+		started = l.state.started
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			now := l.conf().now()
+			entry := &Entry{
+				Severity:  InfoSeverity,
+				Message:   "heartbeat",
+				Fields:    append(append([]*Field{}, fields...), l.Duration("uptime", now.Sub(started))),
+				Timestamp: now,
+			}
+			if l.conf().withSourceLocation(InfoSeverity) {
+				entry.File = relative(file)
+				entry.Line = line
+				entry.Function = runtime.FuncForPC(pc).Name()
+			}
+			l.emit(entry)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
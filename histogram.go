@@ -0,0 +1,139 @@
+package runlogger
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxHistogramSamples is how many durations a Histogram keeps per window,
+// when more are observed a random sample of them is kept
+const maxHistogramSamples = 10000
+
+// Histogram collects durations and logs their percentiles, see
+// Logger.Histogram. It's safe for concurrent use.
+type Histogram struct {
+	l    *Logger
+	name string
+
+	mu      sync.Mutex
+	samples []time.Duration
+	count   int
+}
+
+type histogramValue struct {
+	Name  string        `json:"name"`
+	Count int           `json:"count"`
+	P50   durationValue `json:"p50"`
+	P95   durationValue `json:"p95"`
+	P99   durationValue `json:"p99"`
+}
+
+// Histogram returns a Histogram that collects durations for name, e.g. of
+// an operation, and logs an INFO with how many there were and their 50th,
+// 95th and 99th percentiles when it's flushed:
+//
+//	h := log.Histogram("db query")
+//	stop := h.FlushEvery(time.Minute)
+//	defer stop()
+//	...
+//	h.Observe(time.Since(start))
+//
+// gives {"histogram": {"name": "db query", "count": 1200, "p50": {...}, "p95": {...}, "p99": {...}}}
+// with the percentiles like Duration fields. Close flushes the histograms
+// of the logger too.
+//
+// Calling Histogram again with the same name, with the logger or one
+// derived from it, returns the same Histogram, which logs with the logger
+// it was first made with. So it can be called where the durations are
+// observed, e.g. in a handler, without making a new one every time.
+func (l *Logger) Histogram(name string) *Histogram {
+	s := defaultState
+	if l != nil {
+		s = l.state
+	}
+	s.histogramsMu.Lock()
+	defer s.histogramsMu.Unlock()
+	for _, h := range s.histograms {
+		if h.name == name {
+			return h
+		}
+	}
+	h := &Histogram{l: l, name: name}
+	s.histograms = append(s.histograms, h)
+	return h
+}
+
+// Observe adds d to the current window.
+func (h *Histogram) Observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	if len(h.samples) < maxHistogramSamples {
+		h.samples = append(h.samples, d)
+	} else if i := rand.Intn(h.count); i < maxHistogramSamples {
+		h.samples[i] = d // reservoir sampling keeps every duration equally likely
+	}
+}
+
+// Flush logs the durations observed since the last flush and starts a new
+// window. Nothing is logged if there weren't any.
+func (h *Histogram) Flush() {
+	h.mu.Lock()
+	samples, count := h.samples, h.count
+	h.samples, h.count = nil, 0
+	h.mu.Unlock()
+	if count == 0 {
+		return
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	percentile := func(p float64) durationValue {
+		d := samples[int(math.Ceil(p*float64(len(samples))))-1]
+		return durationValue{d.String(), d.Nanoseconds()}
+	}
+	h.l.emit(&Entry{
+		Severity: InfoSeverity,
+		Message:  "histogram " + h.name,
+		Fields: []*Field{{"histogram", histogramValue{
+			Name:  h.name,
+			Count: count,
+			P50:   percentile(0.50),
+			P95:   percentile(0.95),
+			P99:   percentile(0.99),
+		}}},
+		Timestamp: h.l.conf().now(),
+	})
+}
+
+// FlushEvery flushes the histogram every interval until stop is called.
+// stop waits for the goroutine doing the flushing to end, and can be called
+// more than once.
+func (h *Histogram) FlushEvery(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				h.Flush()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package runlogger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogramIsKeyedByName(t *testing.T) {
+	l, buf := testLogger()
+	for i := 1; i <= 100; i++ {
+		l.Histogram("db query").Observe(time.Duration(i) * time.Millisecond)
+	}
+	l.Histogram("cache").Observe(time.Millisecond)
+	if n := len(l.state.histograms); n != 2 {
+		t.Fatalf("expected one histogram per name, got %d", n)
+	}
+
+	l.Close()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected an entry per histogram, got %q", lines)
+	}
+	h := decode(t, buf)[0]["histogram"].(map[string]interface{})
+	if h["name"] != "db query" || h["count"] != float64(100) {
+		t.Errorf("expected the 100 durations of db query, got %v", h)
+	}
+	if p95 := h["p95"].(map[string]interface{})["string"]; p95 != "95ms" {
+		t.Errorf("expected p95 95ms, got %v", p95)
+	}
+}
@@ -0,0 +1,62 @@
+package runlogger
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const instanceIDURL = "http://metadata.google.internal/computeMetadata/v1/instance/id"
+
+// WithHostname adds a "hostname" field to every entry. On Cloud Run (when
+// K_SERVICE is set) the instance id is also added as "instance_id", it's
+// looked up from the metadata server once per process, the first time the
+// option is used.
+func WithHostname() Option {
+	return func(c *config) {
+		hostInfoOnce.Do(lookupHostInfo)
+		if hostInfo.hostname != "" {
+			c.setFields(&Field{"hostname", hostInfo.hostname})
+		}
+		if hostInfo.instanceID != "" {
+			c.setFields(&Field{"instance_id", hostInfo.instanceID})
+		}
+	}
+}
+
+var (
+	hostInfoOnce sync.Once
+	hostInfo     struct{ hostname, instanceID string }
+)
+
+func lookupHostInfo() {
+	hostInfo.hostname, _ = os.Hostname()
+	if os.Getenv("K_SERVICE") != "" {
+		hostInfo.instanceID = instanceID()
+	}
+}
+
+func instanceID() string {
+	req, err := http.NewRequest(http.MethodGet, instanceIDURL, nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	id, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(id))
+}
@@ -0,0 +1,283 @@
+package runlogger
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPRequest is the httpRequest of a LogEntry, see
+// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest
+type HTTPRequest struct {
+	RequestMethod string `json:"requestMethod,omitempty"`
+	RequestURL    string `json:"requestUrl,omitempty"`
+	RequestSize   string `json:"requestSize,omitempty"`
+	Status        int    `json:"status,omitempty"`
+	ResponseSize  string `json:"responseSize,omitempty"`
+	UserAgent     string `json:"userAgent,omitempty"`
+	RemoteIP      string `json:"remoteIp,omitempty"`
+	Referer       string `json:"referer,omitempty"`
+	Latency       string `json:"latency,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+type httpRequestValue struct {
+	*HTTPRequest
+}
+
+// HTTPRequest returns a field that is logged as the httpRequest of the
+// entry, which the log viewer shows like an access log.
+func (l *Logger) HTTPRequest(r *HTTPRequest) *Field {
+	return &Field{"httpRequest", httpRequestValue{r}}
+}
+
+// MiddlewareOption configures the Middleware.
+type MiddlewareOption func(*middleware)
+
+type middleware struct {
+	l               *Logger
+	next            http.Handler
+	successRate     float64
+	random          func() float64
+	requestHeaders  []string
+	responseHeaders []string
+	repanic         *bool // the WithRepanic of the logger when nil
+}
+
+// WithSuccessSampling only logs the given fraction (0 to 1) of the requests
+// answered with a 2xx status, the other requests are always logged.
+func WithSuccessSampling(rate float64) MiddlewareOption {
+	return func(m *middleware) {
+		m.successRate = rate
+	}
+}
+
+// WithRandom replaces the source of the random numbers (in [0, 1)) used
+// for sampling, e.g. to make it predictable in tests.
+func WithRandom(random func() float64) MiddlewareOption {
+	return func(m *middleware) {
+		m.random = random
+	}
+}
+
+// WithLogRequestHeaders logs the given request headers, when the request
+// has them, in a "request_headers" field. No headers are logged by default,
+// and credentials like Authorization and Cookie are always redacted (see
+// WithRedactedWords).
+func WithLogRequestHeaders(allowlist ...string) MiddlewareOption {
+	return func(m *middleware) {
+		m.requestHeaders = append(m.requestHeaders, allowlist...)
+	}
+}
+
+// WithLogResponseHeaders logs the given response headers, when the
+// response has them, in a "response_headers" field. No headers are logged
+// by default, and credentials like Set-Cookie are always redacted (see
+// WithRedactedWords).
+func WithLogResponseHeaders(allowlist ...string) MiddlewareOption {
+	return func(m *middleware) {
+		m.responseHeaders = append(m.responseHeaders, allowlist...)
+	}
+}
+
+// WithMiddlewareRepanic sets if the Middleware panics again after logging
+// a panic of the handler, for this Middleware only. It overrides
+// WithRepanic, which also applies to Go.
+func WithMiddlewareRepanic(repanic bool) MiddlewareOption {
+	return func(m *middleware) {
+		m.repanic = &repanic
+	}
+}
+
+// Middleware logs every request with an httpRequest, so it shows up as an
+// access log in the log viewer, bound to the trace of the request. 5xx
+// responses are logged as ERROR, 4xx as WARNING and the rest as INFO. The
+// logger bound to the trace is in the context of the request given to next,
+// see FromContext.
+//
+// A panic in next is logged as a CRITICAL entry, formatted for Error
+// Reporting like with Go and with the httpRequest, and answered with a 500
+// if nothing has been written yet. With WithRepanic or
+// WithMiddlewareRepanic the panic continues after it's logged instead, net/http then closes the connection.
+// http.ErrAbortHandler is never logged or recovered.
+func (l *Logger) Middleware(next http.Handler, opts ...MiddlewareOption) http.Handler {
+	m := &middleware{
+		l:           l,
+		next:        next,
+		successRate: 1,
+		random:      rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rw := &responseWriter{ResponseWriter: w}
+	l := m.l.WithTrace(traceFromRequest(r))
+	defer func() {
+		if p := recover(); p != nil {
+			m.recovered(p, l, rw, r, start)
+		}
+	}()
+	m.next.ServeHTTP(rw, r.WithContext(NewContext(r.Context(), l)))
+
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status >= 200 && status < 300 && m.successRate < 1 && m.random() >= m.successRate {
+		return
+	}
+
+	severity := InfoSeverity
+	switch {
+	case status >= 500:
+		severity = ErrorSeverity
+	case status >= 400:
+		severity = WarningSeverity
+	}
+	fields := []*Field{l.HTTPRequest(newHTTPRequest(r, status, rw.size, time.Since(start)))}
+	if headers := allowedHeaders(l.conf(), r.Header, m.requestHeaders); headers != nil {
+		fields = append(fields, &Field{"request_headers", headers})
+	}
+	if headers := allowedHeaders(l.conf(), w.Header(), m.responseHeaders); headers != nil {
+		fields = append(fields, &Field{"response_headers", headers})
+	}
+	l.emit(&Entry{
+		Severity:  severity,
+		Message:   fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, status),
+		Fields:    fields,
+		Timestamp: start,
+	})
+}
+
+// recovered logs the panic p of the handler, it has to be called from the
+// deferred function that recovered
+func (m *middleware) recovered(p interface{}, l *Logger, rw *responseWriter, r *http.Request, start time.Time) {
+	if p == http.ErrAbortHandler {
+		panic(p)
+	}
+	l.logPanic(p, debug.Stack(), l.HTTPRequest(newHTTPRequest(r, http.StatusInternalServerError, rw.size, time.Since(start))))
+	repanic := l.conf().repanic
+	if m.repanic != nil {
+		repanic = *m.repanic
+	}
+	if repanic {
+		panic(p)
+	}
+	if rw.status == 0 {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func allowedHeaders(c *config, header http.Header, allowlist []string) map[string]string {
+	var headers map[string]string
+	for _, name := range allowlist {
+		name = http.CanonicalHeaderKey(name)
+		values, ok := header[name]
+		if !ok {
+			continue
+		}
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		if c.redacts(name) {
+			headers[name] = "[REDACTED]"
+		} else {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+func newHTTPRequest(r *http.Request, status int, size int64, latency time.Duration) *HTTPRequest {
+	req := &HTTPRequest{
+		RequestMethod: r.Method,
+		RequestURL:    r.URL.String(),
+		Status:        status,
+		ResponseSize:  strconv.FormatInt(size, 10),
+		UserAgent:     r.UserAgent(),
+		RemoteIP:      remoteIP(r),
+		Referer:       r.Referer(),
+		Latency:       fmt.Sprintf("%.9fs", latency.Seconds()),
+		Protocol:      r.Proto,
+	}
+	if r.ContentLength > 0 {
+		req.RequestSize = strconv.FormatInt(r.ContentLength, 10)
+	}
+	return req
+}
+
+func remoteIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if i := strings.LastIndex(r.RemoteAddr, ":"); i >= 0 {
+		return strings.Trim(r.RemoteAddr[:i], "[]")
+	}
+	return r.RemoteAddr
+}
+
+// traceFromRequest gets the trace and span from the X-Cloud-Trace-Context
+// or the traceparent header
+func traceFromRequest(r *http.Request) (trace, spanID string) {
+	if header := r.Header.Get("X-Cloud-Trace-Context"); header != "" {
+		// format: TRACE_ID/SPAN_ID;o=TRACE_TRUE
+		trace, spanID = header, ""
+		if i := strings.Index(header, "/"); i >= 0 {
+			trace, spanID = header[:i], header[i+1:]
+			if j := strings.Index(spanID, ";"); j >= 0 {
+				spanID = spanID[:j]
+			}
+			if id, err := strconv.ParseUint(spanID, 10, 64); err == nil {
+				spanID = fmt.Sprintf("%016x", id)
+			}
+		}
+		return trace, spanID
+	}
+	// format: VERSION-TRACE_ID-SPAN_ID-FLAGS
+	if parts := strings.Split(r.Header.Get("traceparent"), "-"); len(parts) == 4 {
+		return parts[1], parts[2]
+	}
+	return "", ""
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the original ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,46 @@
+package runlogger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareRepanic(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		logger  []Option
+		opts    []MiddlewareOption
+		repanic bool
+	}{
+		{"default", nil, nil, false},
+		{"logger", []Option{WithRepanic()}, nil, true},
+		{"middleware", nil, []MiddlewareOption{WithMiddlewareRepanic(true)}, true},
+		{"middleware overrides logger", []Option{WithRepanic()}, []MiddlewareOption{WithMiddlewareRepanic(false)}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			l, buf := testLogger(test.logger...)
+			handler := l.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic("boom")
+			}), test.opts...)
+			w := httptest.NewRecorder()
+
+			repanicked := func() (repanicked bool) {
+				defer func() { repanicked = recover() != nil }()
+				handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+				return false
+			}()
+
+			if repanicked != test.repanic {
+				t.Errorf("expected repanic %t, got %t", test.repanic, repanicked)
+			}
+			if !test.repanic && w.Code != http.StatusInternalServerError {
+				t.Errorf("expected a 500, got %d", w.Code)
+			}
+			if !strings.Contains(buf.String(), `"severity":"CRITICAL"`) {
+				t.Errorf("expected the panic to be logged, got %s", buf)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package runlogger
+
+// maxInsertIDLength is the longest id InsertID uses as the insertId
+const maxInsertIDLength = 128
+
+// InsertID returns a field that sets the insertId of the LogEntry, Cloud
+// Logging shows only one of the entries with the same insertId and
+// timestamp. Give the id of the event that is logged, so an event that is
+// processed again (like a message that is delivered more than once) isn't
+// shown twice:
+//
+//	log.Info("order processed", log.InsertID(msg.ID))
+//
+// The timestamps have to be the same too, a hook (see WithHook) can set
+// the Timestamp of the entry to the time of the event when the retries log
+// it at other times.
+//
+// An empty id, or one longer than 128 bytes, isn't used as the insertId,
+// it's logged as an "insertId" field in jsonPayload instead.
+func (l *Logger) InsertID(id string) *Field {
+	if id == "" || len(id) > maxInsertIDLength {
+		return &Field{"insertId", id}
+	}
+	return &Field{"logging.googleapis.com/insertId", topLevelValue{id}}
+}
@@ -0,0 +1,84 @@
+package runlogger
+
+import "net"
+
+// GeoIP is what a resolver given to WithGeoIP knows about an IP address.
+type GeoIP struct {
+	Country   string  `json:"country,omitempty"` // e.g. "NO"
+	Region    string  `json:"region,omitempty"`
+	City      string  `json:"city,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+}
+
+type ipValue struct {
+	Address  string `json:"address"`
+	Version  int    `json:"version,omitempty"`
+	Valid    bool   `json:"valid"`
+	Redacted bool   `json:"redacted,omitempty"`
+	Geo      *GeoIP `json:"geo,omitempty"`
+}
+
+// IP returns a field with the IP address addr, its version (4 or 6) and if
+// it's valid. A port is left out, so r.RemoteAddr can be given as it is:
+//
+//	log.Info("login", log.IP("client_ip", r.RemoteAddr))
+//
+// gives {"client_ip": {"address": "192.0.2.1", "version": 4, "valid": true}}.
+// An addr that isn't an IP address is logged as it is with "valid": false.
+//
+// With WithGeoIP the field also has a "geo" object, and with WithIPRedaction
+// the end of the address is zeroed.
+func (l *Logger) IP(key, addr string) *Field {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return &Field{key, ipValue{Address: addr}}
+	}
+
+	c := l.conf()
+	value := ipValue{Address: ip.String(), Version: 6, Valid: true}
+	if ip.To4() != nil {
+		value.Version = 4
+	}
+	if c.geoResolver != nil {
+		if geo, ok := c.geoResolver(ip); ok {
+			value.Geo = &geo
+		}
+	}
+	if c.redactIPs {
+		value.Address = redactIP(ip).String()
+		value.Redacted = true
+	}
+	return &Field{key, value}
+}
+
+// WithGeoIP makes IP look the address up with resolve and add what it
+// knows in a "geo" object. resolve is called for every IP field, so it
+// should be fast, like a lookup in a local database. It returns false when
+// it doesn't know the address.
+func WithGeoIP(resolve func(ip net.IP) (GeoIP, bool)) Option {
+	return func(c *config) {
+		c.geoResolver = resolve
+	}
+}
+
+// WithIPRedaction makes IP zero the last octet of IPv4 addresses and the
+// last 80 bits of IPv6 addresses (keeping the /24 and /48 networks), so the
+// logs don't have the addresses of the users. The lookup of WithGeoIP is
+// still done with the whole address.
+func WithIPRedaction(redact bool) Option {
+	return func(c *config) {
+		c.redactIPs = redact
+	}
+}
+
+func redactIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32))
+	}
+	return ip.Mask(net.CIDRMask(48, 128))
+}
@@ -0,0 +1,83 @@
+package runlogger
+
+import (
+	"sync"
+	"time"
+)
+
+// Job logs the lifecycle of a scheduled job, like one triggered by Cloud
+// Scheduler, see Logger.Job. It's safe for concurrent use.
+type Job struct {
+	l    *Logger
+	name string
+
+	mu      sync.Mutex
+	attempt int
+	started time.Time
+}
+
+type jobValue struct {
+	Name     string         `json:"name"`
+	Attempt  int            `json:"attempt"`
+	Duration *durationValue `json:"duration,omitempty"`
+}
+
+// Job returns a Job that logs the runs of the job name with a "job" field
+// that has the name, the attempt (how many times Start has been called)
+// and, when it's done, the duration since the last Start:
+//
+//	job := log.Job("nightly-export")
+//	job.Start()
+//	if err := export(ctx); err != nil {
+//		job.Failure(err)
+//		return
+//	}
+//	job.Success(log.Field("rows", n))
+//
+// Start and Success log an INFO and Failure an ERROR, so the failures are
+// sent to Error Reporting. WithCloseSummary adds the counts for the whole
+// run when the logger is closed.
+func (l *Logger) Job(name string) *Job {
+	return &Job{l: l, name: name}
+}
+
+// Start logs that the job started and starts the next attempt.
+func (j *Job) Start(fields ...*Field) {
+	j.mu.Lock()
+	j.attempt++
+	j.started = j.l.conf().now()
+	value := jobValue{Name: j.name, Attempt: j.attempt}
+	j.mu.Unlock()
+	j.l.writeLog(InfoSeverity, "job "+j.name+" started", append([]*Field{{"job", value}}, fields...))
+}
+
+// Success logs that the job succeeded.
+func (j *Job) Success(fields ...*Field) {
+	value, elapsed := j.done()
+	j.l.writeLog(InfoSeverity, "job "+j.name+" succeeded after "+elapsed.String(), append([]*Field{{"job", value}}, fields...))
+}
+
+// Failure logs that the job failed with err, the fields attached to err
+// with WithFields are logged too. A nil err is logged as a null "error"
+// field, like ErrorCause does.
+func (j *Job) Failure(err error, fields ...*Field) {
+	value, elapsed := j.done()
+	msg := "job " + j.name + " failed after " + elapsed.String()
+	errField := &Field{"error", nil}
+	if err != nil {
+		msg += ": " + err.Error()
+		errField.Value = err.Error()
+	}
+	fields = append(append([]*Field{{"job", value}, errField}, errorFields(err)...), fields...)
+	j.l.writeLog(ErrorSeverity, msg, fields)
+}
+
+func (j *Job) done() (jobValue, time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var elapsed time.Duration
+	if !j.started.IsZero() {
+		elapsed = j.l.conf().now().Sub(j.started)
+	}
+	return jobValue{j.name, j.attempt, &durationValue{elapsed.String(), elapsed.Nanoseconds()}}, elapsed
+}
@@ -0,0 +1,35 @@
+package runlogger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJobFailure(t *testing.T) {
+	for _, test := range []struct {
+		err     error
+		message string
+		field   interface{}
+	}{
+		{errors.New("timeout"), `"message":"job import failed after 0s: timeout"`, "timeout"},
+		{nil, `"message":"job import failed after 0s"`, nil},
+	} {
+		l, buf := testLogger()
+		job := l.Job("import")
+		job.Start()
+		buf.Reset()
+		job.Failure(test.err, l.Field("file", "a.csv"))
+
+		if !strings.Contains(buf.String(), test.message) || !strings.Contains(buf.String(), `"severity":"ERROR"`) {
+			t.Errorf("expected an ERROR with %s, got %s", test.message, buf)
+		}
+		payload := decode(t, buf)[0]
+		if value, ok := payload["error"]; !ok || value != test.field {
+			t.Errorf("expected the error field %v, got %v", test.field, payload)
+		}
+		if payload["file"] != "a.csv" {
+			t.Errorf("expected the file field, got %v", payload)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package runlogger
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WithKeyTransform makes every field key (and label key) go through
+// transform before it's written, also the ones added by options. If two keys
+// transform to the same key the last one wins, like for duplicated keys
+// in general. The keys of TopLevel fields, like InsertID, are LogEntry keys
+// and aren't transformed.
+func WithKeyTransform(transform func(string) string) Option {
+	return func(c *config) {
+		c.keyTransform = transform
+	}
+}
+
+// SnakeCase is a key transform that turns "userID", "UserId", "user-id"
+// and "user id" into "user_id".
+func SnakeCase(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '.':
+			r = '_'
+		case unicode.IsUpper(r):
+			// start a new word at "aB" and at the last upper case of "ABc"
+			if i > 0 && runes[i-1] != '_' && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// transformKeys returns fields with the keys transformed, the given fields
+// are left as is since they might be reused by the caller
+func transformKeys(fields []*Field, transform func(string) string) []*Field {
+	transformed := make([]*Field, len(fields))
+	for i, field := range fields {
+		if _, ok := field.Value.(topLevelValue); ok {
+			transformed[i] = field
+			continue
+		}
+		transformed[i] = &Field{transform(field.Key), field.Value}
+	}
+	return transformed
+}
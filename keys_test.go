@@ -0,0 +1,65 @@
+package runlogger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	for key, want := range map[string]string{
+		"userID":  "user_id",
+		"UserId":  "user_id",
+		"user-id": "user_id",
+		"user id": "user_id",
+		"HTTPUrl": "http_url",
+		"user_id": "user_id",
+	} {
+		if got := SnakeCase(key); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestKeyTransformCollision(t *testing.T) {
+	base := func(c *config) { c.fields = append(c.fields, &Field{"UserID", 0}) }
+	l, buf := testLogger(WithKeyTransform(SnakeCase), base)
+	l.Info("collision", l.Field("userID", 1), l.Field("user_id", 2), l.Field("UserId", 3))
+	payload := decode(t, buf)[0]
+	if len(payload) != 1 {
+		t.Fatalf("expected the keys to collapse into one, got %v", payload)
+	}
+	if got := payload["user_id"]; got != 3.0 {
+		t.Errorf("expected the last field to win, got %v", got)
+	}
+}
+
+func TestKeyTransformBaseFields(t *testing.T) {
+	base := func(c *config) { c.fields = append(c.fields, &Field{"serviceName", "api"}) }
+	l, buf := testLogger(WithKeyTransform(SnakeCase), base)
+	l.Info("base")
+	if got := decode(t, buf)[0]["service_name"]; got != "api" {
+		t.Errorf("expected the base field key to be transformed, got %v", got)
+	}
+}
+
+func TestKeyTransformLabels(t *testing.T) {
+	l, buf := testLogger(WithKeyTransform(SnakeCase))
+	l.Info("label", l.Label("deployEnv", "prod"))
+	if got := buf.String(); !strings.Contains(got, `"logging.googleapis.com/labels":{"deploy_env":"prod"}`) {
+		t.Errorf("expected the label key to be transformed, got %s", got)
+	}
+}
+
+func TestKeyTransformKeepsTopLevelKeys(t *testing.T) {
+	l, buf := testLogger(WithKeyTransform(SnakeCase), WithTopLevel("logging.googleapis.com/operation", map[string]string{"id": "op"}))
+	l.Info("retried", l.InsertID("event-1"), l.Field("eventID", 1))
+	for _, want := range []string{
+		`"logging.googleapis.com/insertId":"event-1"`,
+		`"logging.googleapis.com/operation":{"id":"op"}`,
+		`"event_id":1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in %s", want, buf)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package runlogger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type labelValue string
+
+var highCardinality = regexp.MustCompile(`(?i)^(?:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-f]{16,}|[0-9]{6,})$`)
+
+// Label returns a field that is sent as a label (logging.googleapis.com/labels)
+// instead of in jsonPayload. Labels are indexed, so they should only be used
+// for values with few distinct values, like an environment or a tenant.
+//
+// To keep unique values from ending up in the index, labels whose key looks
+// like an id (e.g. "request_id") or whose whole value looks like one (a
+// uuid, a long hex string or number) are put in jsonPayload instead, and a
+// warning is logged after the entry the first time it happens for a key.
+func (l *Logger) Label(key, value string) *Field {
+	return &Field{key, labelValue(value)}
+}
+
+// extractLabels moves the label fields out of the entry's fields, it
+// returns the warnings to log after the entry is written
+func (l *Logger) extractLabels(entry *Entry) (warnings []string) {
+	var fields []*Field
+	for _, field := range entry.Fields {
+		value, ok := field.Value.(labelValue)
+		if !ok {
+			fields = append(fields, field)
+			continue
+		}
+		if isIDKey(field.Key) || highCardinality.MatchString(string(value)) {
+			fields = append(fields, &Field{field.Key, string(value)})
+			if _, warned := l.state.warnedLabels.LoadOrStore(field.Key, true); !warned {
+				warnings = append(warnings, fmt.Sprintf("label %q (logged in [%s:%d]) looks like it has a unique value per entry, it's logged in jsonPayload instead", field.Key, entry.File, entry.Line))
+			}
+			continue
+		}
+		if entry.Labels == nil {
+			entry.Labels = map[string]string{}
+		}
+		entry.Labels[field.Key] = string(value)
+	}
+	entry.Fields = fields
+	return warnings
+}
+
+func isIDKey(key string) bool {
+	lower := strings.ToLower(key)
+	return lower == "id" || strings.HasSuffix(lower, "_id") || strings.HasSuffix(lower, "-id") || strings.HasSuffix(key, "Id") || strings.HasSuffix(key, "ID")
+}
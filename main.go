@@ -2,37 +2,74 @@ package runlogger
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
-type severety string
+// Severity is the severity of a log entry, see https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#logseverity
+type Severity string
 
 const (
-	default_severety   severety = "DEFAULT"   // The log entry has no assigned severity level.
-	debug_severety     severety = "DEBUG"     // Debug or trace information.
-	info_severety      severety = "INFO"      // Routine information, such as ongoing status or performance.
-	notice_severety    severety = "NOTICE"    // Normal but significant events, such as start up, shut down, or a configuration change.
-	warning_severety   severety = "WARNING"   // Warning events might cause problems.
-	error_severety     severety = "ERROR"     // Error events are likely to cause problems.
-	critical_severety  severety = "CRITICAL"  // Critical events cause more severe problems or outages.
-	alert_severety     severety = "ALERT"     // A person must take an action immediately.
-	emergency_severety severety = "EMERGENCY" // One or more systems are unusable.)
+	DefaultSeverity   Severity = "DEFAULT"   // The log entry has no assigned severity level.
+	DebugSeverity     Severity = "DEBUG"     // Debug or trace information.
+	InfoSeverity      Severity = "INFO"      // Routine information, such as ongoing status or performance.
+	NoticeSeverity    Severity = "NOTICE"    // Normal but significant events, such as start up, shut down, or a configuration change.
+	WarningSeverity   Severity = "WARNING"   // Warning events might cause problems.
+	ErrorSeverity     Severity = "ERROR"     // Error events are likely to cause problems.
+	CriticalSeverity  Severity = "CRITICAL"  // Critical events cause more severe problems or outages.
+	AlertSeverity     Severity = "ALERT"     // A person must take an action immediately.
+	EmergencySeverity Severity = "EMERGENCY" // One or more systems are unusable.)
 )
 
+var severityNumbers = map[Severity]int{
+	DefaultSeverity:   0,
+	DebugSeverity:     100,
+	InfoSeverity:      200,
+	NoticeSeverity:    300,
+	WarningSeverity:   400,
+	ErrorSeverity:     500,
+	CriticalSeverity:  600,
+	AlertSeverity:     700,
+	EmergencySeverity: 800,
+}
+
+// Number returns the number of the severity in the LogSeverity enum, from
+// 0 for DEFAULT and 100 for DEBUG up to 800 for EMERGENCY.
+func (s Severity) Number() int {
+	return severityNumbers[s]
+}
+
 const maxSize = 102400
 
 var errorMessageType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
 
 var stdout = bufio.NewWriter(os.Stdout)
 
-type Logger struct{}
+type Logger struct {
+	plain  bool
+	early  bool   // from Default before SetDefault was called
+	state  *state // has the config, shared with the loggers derived from it
+	trace  string
+	spanID string
+
+	taps []func(*Entry) // get every entry after it has been written
+
+	onceKey string // only write the first entry with this key
+
+	fields []*Field // added to every entry of this logger, after the ones of the config
+
+	ctx context.Context // from WithContext, only kept with WithContextErrors or by early loggers
+}
 
 type Field struct {
 	Key   string
@@ -46,15 +83,49 @@ func (l *Logger) Field(key string, field interface{}) *Field {
 var prefixPath string
 
 // StructuredLogger is used to have structured logging in stackdriver (Google Cloud Platform)
-func StructuredLogger() *Logger {
+func StructuredLogger(opts ...Option) *Logger {
 	setPrefixPath()
-	return &Logger{}
+	return &Logger{state: newState(newConfig(opts))}
 }
 
 // PlainLogger is used when you are not in a cloud run environment
-func PlainLogger() *Logger {
+func PlainLogger(opts ...Option) *Logger {
 	setPrefixPath()
-	return nil
+	return &Logger{plain: true, state: newState(newConfig(opts))}
+}
+
+// WithTrace returns a copy of the logger where every entry is bound to the
+// given trace and span, so they are grouped together in the log viewer.
+// If GOOGLE_CLOUD_PROJECT is set a bare trace id is expanded to the
+// full "projects/<project>/traces/<trace>" resource name.
+func (l *Logger) WithTrace(trace, spanID string) *Logger {
+	if l == nil {
+		return nil
+	}
+	if project := os.Getenv("GOOGLE_CLOUD_PROJECT"); project != "" && trace != "" && !strings.HasPrefix(trace, "projects/") {
+		trace = "projects/" + project + "/traces/" + trace
+	}
+	child := *l
+	child.trace = trace
+	child.spanID = spanID
+	return &child
+}
+
+// withTap returns a copy of the logger that also gives every entry to tap
+func (l *Logger) withTap(tap func(*Entry)) *Logger {
+	child := l.clone()
+	child.taps = append(append([]func(*Entry){}, child.taps...), tap)
+	return child
+}
+
+// clone returns a copy of the logger to derive a new logger from, a
+// nil logger gives a plain logger
+func (l *Logger) clone() *Logger {
+	if l == nil {
+		return &Logger{plain: true, state: defaultState}
+	}
+	child := *l
+	return &child
 }
 
 func setPrefixPath() {
@@ -62,167 +133,418 @@ func setPrefixPath() {
 	prefixPath = filepath.Dir(fileName) + "/"
 }
 
+// Default logs without asserting a severity, the log viewer shows it as unspecified
+func (l *Logger) Default(v ...interface{}) {
+	inputs, fields := extractFields(v)
+	l.writeLog(DefaultSeverity, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+}
+
 func (l *Logger) Debug(v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(debug_severety, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+	l.writeLog(DebugSeverity, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
 }
 
 func (l *Logger) Info(v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(info_severety, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+	l.writeLog(InfoSeverity, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
 }
 
 func (l *Logger) Notice(v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(notice_severety, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+	l.writeLog(NoticeSeverity, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
 }
 
 func (l *Logger) Warning(v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(warning_severety, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+	l.writeLog(WarningSeverity, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
 }
 
 func (l *Logger) Error(v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(error_severety, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+	l.writeLog(l.conf().errorSeverity(v), strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
 }
 
 func (l *Logger) Critical(v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(critical_severety, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+	l.writeLog(CriticalSeverity, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
 }
 
 func (l *Logger) Alert(v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(alert_severety, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+	l.writeLog(AlertSeverity, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
 }
 
 func (l *Logger) Emergency(v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(emergency_severety, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+	l.writeLog(EmergencySeverity, strings.TrimSpace(fmt.Sprintln(inputs...)), fields)
+}
+
+func (l *Logger) Defaultf(format string, v ...interface{}) {
+	inputs, fields := extractFields(v)
+	l.writeLog(DefaultSeverity, fmt.Sprintf(format, inputs...), fields)
 }
 
 func (l *Logger) Debugf(format string, v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(debug_severety, fmt.Sprintf(format, inputs...), fields)
+	l.writeLog(DebugSeverity, fmt.Sprintf(format, inputs...), fields)
 }
 
 func (l *Logger) Infof(format string, v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(info_severety, fmt.Sprintf(format, inputs...), fields)
+	l.writeLog(InfoSeverity, fmt.Sprintf(format, inputs...), fields)
 }
 
 func (l *Logger) Noticef(format string, v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(notice_severety, fmt.Sprintf(format, inputs...), fields)
+	l.writeLog(NoticeSeverity, fmt.Sprintf(format, inputs...), fields)
 }
 
 func (l *Logger) Warningf(format string, v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(warning_severety, fmt.Sprintf(format, inputs...), fields)
+	l.writeLog(WarningSeverity, fmt.Sprintf(format, inputs...), fields)
 }
 
 func (l *Logger) Errorf(format string, v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(error_severety, fmt.Sprintf(format, inputs...), fields)
+	l.writeLog(l.conf().errorSeverity(v), fmt.Sprintf(format, inputs...), fields)
 }
 
 func (l *Logger) Criticalf(format string, v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(critical_severety, fmt.Sprintf(format, inputs...), fields)
+	l.writeLog(CriticalSeverity, fmt.Sprintf(format, inputs...), fields)
 }
 
 func (l *Logger) Alertf(format string, v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(alert_severety, fmt.Sprintf(format, inputs...), fields)
+	l.writeLog(AlertSeverity, fmt.Sprintf(format, inputs...), fields)
 }
 
 func (l *Logger) Emergencyf(format string, v ...interface{}) {
 	inputs, fields := extractFields(v)
-	l.writeLog(emergency_severety, fmt.Sprintf(format, inputs...), fields)
+	l.writeLog(EmergencySeverity, fmt.Sprintf(format, inputs...), fields)
 }
 
-func (l *Logger) writeLog(severety severety, message string, fields []*Field) {
-	output := os.Stderr
+// Defaultw logs msg with fields from alternating keys and values, e.g.
+// Defaultw("user logged in", "user", id, "admin", false). *Field values can
+// be mixed in without a key. A key that isn't a string, or a key without a
+// value, is logged under the "!BADKEY" key.
+func (l *Logger) Defaultw(msg string, keysAndValues ...interface{}) {
+	l.writeLog(DefaultSeverity, msg, pairsToFields(keysAndValues))
+}
 
-	var isError bool
-	switch severety {
-	case error_severety, critical_severety, alert_severety, emergency_severety:
-		isError = true
-	}
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.writeLog(DebugSeverity, msg, pairsToFields(keysAndValues))
+}
 
-	pc, file, line, _ := runtime.Caller(2)
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.writeLog(InfoSeverity, msg, pairsToFields(keysAndValues))
+}
 
-	if l == nil {
-		if len(fields) > 0 {
-			j, _ := json.Marshal(fields)
-			fmt.Fprintf(
-				output,
-				"%s in [%s:%d]: %s\n%s\n",
-				severety,
-				relative(file),
-				line,
-				message,
-				j,
-			)
-		} else {
-			fmt.Fprintf(
-				output,
-				"%s in [%s:%d]: %s\n",
-				severety,
-				relative(file),
-				line,
-				message,
-			)
+func (l *Logger) Noticew(msg string, keysAndValues ...interface{}) {
+	l.writeLog(NoticeSeverity, msg, pairsToFields(keysAndValues))
+}
+
+func (l *Logger) Warningw(msg string, keysAndValues ...interface{}) {
+	l.writeLog(WarningSeverity, msg, pairsToFields(keysAndValues))
+}
+
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.writeLog(l.conf().errorSeverity(keysAndValues), msg, pairsToFields(keysAndValues))
+}
+
+func (l *Logger) Criticalw(msg string, keysAndValues ...interface{}) {
+	l.writeLog(CriticalSeverity, msg, pairsToFields(keysAndValues))
+}
+
+func (l *Logger) Alertw(msg string, keysAndValues ...interface{}) {
+	l.writeLog(AlertSeverity, msg, pairsToFields(keysAndValues))
+}
+
+func (l *Logger) Emergencyw(msg string, keysAndValues ...interface{}) {
+	l.writeLog(EmergencySeverity, msg, pairsToFields(keysAndValues))
+}
+
+func (l *Logger) writeLog(severity Severity, message string, fields []*Field) {
+	c := l.conf()
+	if !c.enabled(severity) {
+		return
+	}
+	entry := &Entry{
+		Severity:  severity,
+		Message:   message,
+		Fields:    fields,
+		Timestamp: c.now(),
+	}
+	if c.withSourceLocation(severity) {
+		pc, file, line, _ := runtime.Caller(2)
+		entry.File = relative(file)
+		entry.Line = line
+		entry.Function = runtime.FuncForPC(pc).Name()
+		if n := c.callerFrames; n > 1 {
+			entry.Callers = callers(3, n)
 		}
+	}
+	l.emit(entry)
+}
+
+// emit adds what the logger is bound to to the entry, runs the hooks and writes it
+func (l *Logger) emit(entry *Entry) {
+	if l != nil && l.early {
+		emitEarly(l, entry)
+		return
+	}
+	c := l.conf()
+	if !c.enabled(entry.Severity) {
 		return
 	}
+	if c.dropEmptyMessages && entry.Message == "" && len(entry.Fields) == 0 {
+		return
+	}
+	var measured allocMeasurement
+	if c.entryStats != nil {
+		measured.start()
+	}
+	if c.utc {
+		entry.Timestamp = entry.Timestamp.UTC()
+	} else {
+		entry.Timestamp = entry.Timestamp.Local()
+	}
+	if l != nil {
+		if l.onceKey != "" && !firstTime(l.onceKey) {
+			return
+		}
+		// warnings about the entry are logged after it, a hook or an output
+		// that logs would otherwise be called again in the middle of it
+		var warnings []string
+		defer func() {
+			for _, warning := range warnings {
+				l.Warning(warning)
+			}
+		}()
+		if len(c.fields) > 0 || len(l.fields) > 0 {
+			entry.Fields = append(append(append([]*Field{}, c.fields...), l.fields...), entry.Fields...)
+		}
+		if l.ctx != nil && c.contextErrors != nil {
+			l.addContextError(entry, *c.contextErrors)
+		}
+		if c.sequenceNumbers {
+			entry.Fields = append(entry.Fields, &Field{"seq", atomic.AddUint64(&sequence, 1)})
+		}
+		if l.trace != "" {
+			entry.Trace = l.trace
+			entry.SpanID = l.spanID
+		}
+		if c.messagePrefix != "" {
+			entry.Message = c.messagePrefix + " " + entry.Message
+		}
+		if c.keyTransform != nil {
+			entry.Fields = transformKeys(entry.Fields, c.keyTransform)
+		}
+		warnings = append(warnings, l.extractLabels(entry)...)
+		warnings = append(warnings, l.extractPointers(entry)...)
+		extractHTTPRequest(entry)
+		extractTopLevel(entry, c)
+		if c.omitNilFields {
+			entry.Fields = omitNilFields(entry.Fields)
+		}
+		if c.maxFields > 0 && len(entry.Fields) > c.maxFields {
+			dropped := len(entry.Fields) - c.maxFields
+			entry.Fields = append(entry.Fields[:c.maxFields:c.maxFields], &Field{"fields_truncated", dropped})
+		}
+
+		if c.alertStorm != nil {
+			if severity, downgraded := l.state.alertStorm.downgrade(c.alertStorm, entry.Severity, entry.Timestamp); downgraded {
+				entry.Fields = append(entry.Fields, &Field{"downgraded_from", entry.Severity})
+				entry.Severity = severity
+			}
+		}
+
+		for _, hook := range c.hooks {
+			modified, ok := hook(entry)
+			if !ok {
+				return
+			}
+			if modified != nil {
+				entry = modified
+			}
+		}
+		warnings = append(warnings, l.reservedKeyWarnings(entry)...)
+	}
+
+	entry.Fields = resolveRawOnce(entry.Fields)
+	size := l.write(entry)
+	if c.entryStats != nil {
+		c.entryStats(measured.done(entry.Severity, size))
+	}
+
+	if l != nil {
+		l.state.emitted(entry.Severity)
+		for _, callback := range c.emitCallbacks {
+			callback(entry.Severity)
+		}
+		for _, tap := range l.taps {
+			tap(entry)
+		}
+		if dropped := l.state.subscribers.publish(entry); dropped > 0 {
+			c.handleError(fmt.Errorf("runlogger: dropped an entry for %d slow subscribers", dropped))
+		}
+	} else {
+		if dropped := defaultState.subscribers.publish(entry); dropped > 0 {
+			c.handleError(fmt.Errorf("runlogger: dropped an entry for %d slow subscribers", dropped))
+		}
+	}
+}
+
+// write formats the entry and writes it, it returns the size of the
+// formatted entry
+func (l *Logger) write(entry *Entry) int {
+	c := l.conf()
+	if len(c.sinks) > 0 {
+		l.writeSinks(entry)
+	}
+	var (
+		j   []byte
+		err error
+	)
+	switch {
+	case c.formatter != nil:
+		j, err = c.formatter.Format(entry)
+	case l == nil || l.plain:
+		j = formatPlain(entry)
+		l.writeLine(j)
+		return len(j)
+	default:
+		j, err = l.formatStackdriver(entry)
+	}
+	if err != nil {
+		c.handleError(fmt.Errorf("runlogger: could not format entry %q: %w", entry.Message, err))
+		return 0
+	}
+
+	if len(j) >= maxSize {
+		// json escaping can make a string up to 6 times longer, so an 8th
+		// of maxSize is sure to fit when it's logged again
+		l.Errorf("log entry of %d bytes exeed max size of %d bytes: %s", len(j), maxSize, truncate(string(j), maxSize/8))
+	} else {
+		l.writeLine(j)
+	}
+	return len(j)
+}
+
+func formatPlain(entry *Entry) []byte {
+	var location string
+	if entry.File != "" {
+		location = fmt.Sprintf(" in [%s:%d]", entry.File, entry.Line)
+	}
+	fields := entry.Fields
+	labelKeys := make([]string, 0, len(entry.Labels))
+	for key := range entry.Labels {
+		labelKeys = append(labelKeys, key)
+	}
+	sort.Strings(labelKeys)
+	for _, key := range labelKeys {
+		fields = append(fields, &Field{key, entry.Labels[key]})
+	}
+	if entry.HTTPRequest != nil {
+		fields = append(fields, &Field{"httpRequest", entry.HTTPRequest})
+	}
+	topLevelKeys := make([]string, 0, len(entry.TopLevel))
+	for key := range entry.TopLevel {
+		topLevelKeys = append(topLevelKeys, key)
+	}
+	sort.Strings(topLevelKeys)
+	for _, key := range topLevelKeys {
+		fields = append(fields, &Field{key, entry.TopLevel[key]})
+	}
+	if len(fields) > 0 {
+		j, _ := json.Marshal(fields)
+		return []byte(fmt.Sprintf(
+			"%s%s: %s\n%s",
+			entry.Severity,
+			location,
+			entry.Message,
+			j,
+		))
+	}
+	return []byte(fmt.Sprintf(
+		"%s%s: %s",
+		entry.Severity,
+		location,
+		entry.Message,
+	))
+}
 
+func (l *Logger) formatStackdriver(entry *Entry) ([]byte, error) {
 	var (
 		messageType    *string
 		serviceContext *ServiceContext
 	)
-	if isError {
-		output = os.Stderr
+	switch entry.Severity {
+	case ErrorSeverity, CriticalSeverity, AlertSeverity, EmergencySeverity:
 		messageType = &errorMessageType
 	}
-	if os.Getenv("K_SERVICE") != "" {
+	c := l.conf()
+	mode := c.serviceContext
+	if os.Getenv("K_SERVICE") != "" && (mode == ServiceContextAlways || mode == ServiceContextOnErrors && messageType != nil) && !c.strips(entry.Severity, ServiceContextMetadata) {
 		serviceContext = &ServiceContext{
 			Service: os.Getenv("K_SERVICE"),
 		}
 	}
 
 	jPayload := map[string]interface{}{}
-	for _, field := range fields {
-		if field.Key == "message" {
-			field.Key = "_message_" // this is to prevent the main message from beeing overwritten
+	for _, field := range entry.Fields {
+		key := field.Key
+		if c.isReservedKey(key) {
+			key = l.renameReservedKey(key) // this is to prevent the main message from beeing overwritten
 		}
-		jPayload[field.Key] = field.Value
+		jPayload[key] = field.Value
+	}
+	if max := l.conf().maxPayloadBytes; max > 0 {
+		l.trimPayload(jPayload, max)
+	} else {
+		l.budgetRaw(jPayload, maxSize)
 	}
 
 	payload := &stackdriverLogStruct{
-		JsonPayload: jPayload,
-		Message:     message,
-		Severity:    severety,
-		Timestamp:   time.Now(),
-		Type:        messageType,
-		SourceLocation: &sourceLocation{
-			File:     relative(file),
-			Function: runtime.FuncForPC(pc).Name(),
-			Line:     strconv.Itoa(line),
-		},
+		JsonPayload:    jPayload,
+		Message:        entry.Message,
+		Severity:       l.conf().severityValue(entry.Severity),
+		Timestamp:      entry.Timestamp,
+		Type:           messageType,
 		ServiceContext: serviceContext,
+		Trace:          entry.Trace,
+		SpanID:         entry.SpanID,
+		Labels:         entry.Labels,
+		HTTPRequest:    entry.HTTPRequest,
 	}
-	j, err := json.Marshal(payload)
-	if err != nil {
-		panic("could not log because of err: " + err.Error())
+	for _, frame := range entry.Callers {
+		payload.SourceLocations = append(payload.SourceLocations, &sourceLocation{
+			File:     frame.File,
+			Function: frame.Function,
+			Line:     strconv.Itoa(frame.Line),
+		})
 	}
-
-	if len(j) >= maxSize {
-		l.Errorf("log entry exeed max size of %d bytes: %.100000s", maxSize, j)
-	} else {
-		fmt.Fprintf(output, "%s\n", j)
+	topLevel := entry.TopLevel
+	if (entry.File != "" || entry.Function != "") && !c.strips(entry.Severity, SourceLocationMetadata) {
+		location := &sourceLocation{
+			File:     entry.File,
+			Function: entry.Function,
+			Line:     strconv.Itoa(entry.Line),
+		}
+		if key := l.conf().sourceLocationKey; key != "" {
+			topLevel = map[string]interface{}{key: location}
+			for key, value := range entry.TopLevel {
+				topLevel[key] = value
+			}
+		} else {
+			payload.SourceLocation = location
+		}
+	}
+	j, err := l.conf().marshal(payload)
+	if err != nil {
+		return nil, err
 	}
+	return l.addTopLevel(j, topLevel)
 }
 
 func relative(path string) string {
@@ -232,11 +554,46 @@ func relative(path string) string {
 	return path
 }
 
+// pairsToFields makes fields from alternating keys and values
+func pairsToFields(keysAndValues []interface{}) (fields []*Field) {
+	for i := 0; i < len(keysAndValues); i++ {
+		if field, ok := keysAndValues[i].(*Field); ok {
+			fields = append(fields, field)
+			continue
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok || i == len(keysAndValues)-1 {
+			fields = append(fields, &Field{"!BADKEY", keysAndValues[i]})
+			continue
+		}
+		fields = append(fields, &Field{key, keysAndValues[i+1]})
+		i++
+	}
+	return
+}
+
+// truncate cuts s to at most n bytes without splitting a multi-byte rune
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 func extractFields(inputs []interface{}) (cleanInputs []interface{}, fields []*Field) {
 	for _, input := range inputs {
-		if field, ok := input.(*Field); ok {
-			fields = append(fields, field)
-		} else {
+		switch input := input.(type) {
+		case *Field:
+			fields = append(fields, input)
+		case []*Field:
+			fields = append(fields, input...)
+		case error:
+			fields = append(fields, errorFields(input)...)
+			cleanInputs = append(cleanInputs, input)
+		default:
 			cleanInputs = append(cleanInputs, input)
 		}
 	}
@@ -247,11 +604,17 @@ func extractFields(inputs []interface{}) (cleanInputs []interface{}, fields []*F
 type stackdriverLogStruct struct {
 	Message        string                 `json:"message"`
 	JsonPayload    map[string]interface{} `json:"jsonPayload,omitempty"`
-	Severity       severety               `json:"severity"`
+	Severity       interface{}            `json:"severity"` // a Severity or its number
 	Timestamp      time.Time              `json:"timestamp"`
-	SourceLocation *sourceLocation        `json:"logging.googleapis.com/sourceLocation"`
+	SourceLocation *sourceLocation        `json:"logging.googleapis.com/sourceLocation,omitempty"`
 	Type           *string                `json:"@type,omitempty"`
 	ServiceContext *ServiceContext        `json:"serviceContext,omitempty"`
+	Trace          string                 `json:"logging.googleapis.com/trace,omitempty"`
+	SpanID         string                 `json:"logging.googleapis.com/spanId,omitempty"`
+	Labels         map[string]string      `json:"logging.googleapis.com/labels,omitempty"`
+	HTTPRequest    *HTTPRequest           `json:"httpRequest,omitempty"`
+
+	SourceLocations []*sourceLocation `json:"sourceLocations,omitempty"`
 }
 type ServiceContext struct {
 	Service string `json:"service"`
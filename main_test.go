@@ -0,0 +1,49 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateMultiByteBoundary(t *testing.T) {
+	for _, r := range []string{"😀", "日本"} {
+		s := strings.Repeat("a", 10) + strings.Repeat(r, 4)
+		for n := 10; n <= len(s); n++ {
+			got := truncate(s, n)
+			if !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) = %q, not valid UTF-8", s, n, got)
+			}
+			if len(got) > n || n-len(got) >= utf8.UTFMax {
+				t.Errorf("truncate(%q, %d) has %d bytes", s, n, len(got))
+			}
+		}
+	}
+}
+
+func TestOversizedEntryStaysValidUTF8(t *testing.T) {
+	for _, r := range []string{"😀", "日"} {
+		l, buf := testLogger()
+		// shift the runes over the boundary of the re-logged entry
+		for offset := 0; offset < utf8.UTFMax; offset++ {
+			l.Info(strings.Repeat("a", offset) + strings.Repeat(r, maxSize/len(r)))
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != utf8.UTFMax {
+			t.Fatalf("expected %d entries, got %d", utf8.UTFMax, len(lines))
+		}
+		for _, line := range lines {
+			if !strings.Contains(line, "exeed max size") {
+				t.Fatalf("expected the entry to be re-logged truncated, got ...%q", line[len(line)-20:])
+			}
+			if !utf8.ValidString(line) {
+				t.Fatalf("entry is not valid UTF-8: ...%q", line[len(line)-20:])
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("entry is not valid JSON: %v", err)
+			}
+		}
+	}
+}
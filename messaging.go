@@ -0,0 +1,26 @@
+package runlogger
+
+type messagingValue struct {
+	Topic     string `json:"topic"`
+	MessageID string `json:"message_id,omitempty"`
+	Direction string `json:"direction"` // "publish" or "receive"
+}
+
+// Published logs an INFO that a message was published to a topic (or
+// queue, exchange or the like, for any broker) with a "messaging" field:
+//
+//	{"messaging": {"topic": "orders", "message_id": "123", "direction": "publish"}}
+//
+// Log with a logger bound to the trace (see WithTrace) to follow a message
+// from the publisher to the subscribers.
+func (l *Logger) Published(topic, messageID string, fields ...*Field) {
+	fields = append(fields[:len(fields):len(fields)], &Field{"messaging", messagingValue{topic, messageID, "publish"}})
+	l.writeLog(InfoSeverity, "published message "+messageID+" to "+topic, fields)
+}
+
+// Received logs an INFO that a message was received from a topic, like
+// Published with "receive" as the direction.
+func (l *Logger) Received(topic, messageID string, fields ...*Field) {
+	fields = append(fields[:len(fields):len(fields)], &Field{"messaging", messagingValue{topic, messageID, "receive"}})
+	l.writeLog(InfoSeverity, "received message "+messageID+" from "+topic, fields)
+}
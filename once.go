@@ -0,0 +1,22 @@
+package runlogger
+
+import "sync"
+
+var onceKeys sync.Map // keys that have been logged with Once
+
+// Once returns a copy of the logger that only writes the first entry
+// logged with key in the lifetime of the process, the following ones are
+// dropped. E.g. for a deprecation notice logged per request:
+//
+//	log.Once("deprecated-header").Warning("the X-Old header is deprecated")
+func (l *Logger) Once(key string) *Logger {
+	child := l.clone()
+	child.onceKey = key
+	return child
+}
+
+// firstTime tells if nothing has been logged with the key before
+func firstTime(key string) bool {
+	_, logged := onceKeys.LoadOrStore(key, true)
+	return !logged
+}
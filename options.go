@@ -0,0 +1,588 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// Option configures a logger, pass them to StructuredLogger or PlainLogger.
+type Option func(*config)
+
+type config struct {
+	fields []*Field // added to every entry
+	hooks  []func(*Entry) (*Entry, bool)
+
+	emitCallbacks []func(Severity)
+
+	maxBytes int // max length of a Bytes field
+
+	sourceLocationFor map[Severity]bool // nil means all severities
+	sourceLocationKey string            // "" means logging.googleapis.com/sourceLocation
+
+	withoutMetadata map[Severity]map[Metadata]bool
+
+	keyTransform func(string) string
+
+	reservedKeyFormat  string
+	reservedKeyWarning bool
+	messageKey         string // "" means the reservedKeyFormat
+
+	formatter Formatter // nil means the default for the logger
+
+	alertStorm *alertStorm
+
+	output     io.Writer
+	unbuffered bool
+
+	repanic bool
+
+	marshal func(interface{}) ([]byte, error)
+
+	messagePrefix string
+
+	callerFrames int
+
+	numericSeverity bool
+
+	now func() time.Time
+
+	minSeverity Severity
+
+	sequenceNumbers bool
+
+	closeSummary bool
+
+	omitNilFields bool
+
+	contextKeys   []contextKey
+	contextErrors *bool // nil means off, else if the severity is escalated
+
+	utc bool
+
+	entryStats func(EntryStats)
+
+	serviceContext ServiceContextMode
+
+	maxPayloadBytes int
+
+	maxFields int
+
+	dropEmptyMessages bool
+
+	errorHandler func(error)
+
+	severityMapper func(error) Severity
+
+	sinks []*sink
+
+	newCorrelationID func() string
+
+	redactIPs     bool
+	redactedWords []string
+	geoResolver   func(net.IP) (GeoIP, bool)
+}
+
+// setFields replaces the fields added to every entry that have the keys of
+// fields and adds the others, so an option that adds a field can be given
+// again (like to Reconfigure) without adding it twice. The slice is copied
+// since it can be shared with the config it was copied from.
+func (c *config) setFields(fields ...*Field) {
+	updated := append([]*Field{}, c.fields...)
+	for _, field := range fields {
+		replaced := false
+		for i, existing := range updated {
+			if existing.Key == field.Key {
+				updated[i] = field
+				replaced = true
+			}
+		}
+		if !replaced {
+			updated = append(updated, field)
+		}
+	}
+	c.fields = updated
+}
+
+// defaultConfig is used by nil loggers
+var defaultConfig = newConfig(nil)
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		maxBytes:          1024,
+		reservedKeyFormat: "_%s_",
+		output:            os.Stderr,
+		marshal:           json.Marshal,
+		now:               time.Now,
+		utc:               true,
+		redactedWords:     append([]string{}, defaultRedactedWords...),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// conf returns the config of the logger, also for a nil logger
+func (l *Logger) conf() *config {
+	if l == nil {
+		return defaultConfig
+	}
+	return l.state.config.Load().(*config)
+}
+
+// Reconfigure applies opts to the config of the logger, on top of the
+// options it already has, e.g. to change the min severity from an admin
+// endpoint during an incident:
+//
+//	log.Reconfigure(runlogger.WithMinSeverity(runlogger.DebugSeverity))
+//
+// The config is shared by all the loggers derived from the same
+// StructuredLogger or PlainLogger (like with WithTrace or WithContext), so
+// reconfiguring any of them reconfigures the logger it was derived from and
+// all the others too. Options that add a field, like WithHostname or
+// WithTopLevel, replace the field when they are given again.
+//
+// The config is swapped atomically, so an entry is always written with
+// either the old or the new config. A nil logger can't be reconfigured.
+func (l *Logger) Reconfigure(opts ...Option) {
+	if l == nil || l.early {
+		return
+	}
+	l.state.reconfigure.Lock()
+	defer l.state.reconfigure.Unlock()
+
+	old := l.conf()
+	c := *old
+	// clip the slices so the options append to copies of them
+	c.fields = c.fields[:len(c.fields):len(c.fields)]
+	c.hooks = c.hooks[:len(c.hooks):len(c.hooks)]
+	c.emitCallbacks = c.emitCallbacks[:len(c.emitCallbacks):len(c.emitCallbacks)]
+	c.contextKeys = c.contextKeys[:len(c.contextKeys):len(c.contextKeys)]
+	c.sinks = c.sinks[:len(c.sinks):len(c.sinks)]
+	c.redactedWords = c.redactedWords[:len(c.redactedWords):len(c.redactedWords)]
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	l.state.mu.Lock()
+	if c.output != old.output {
+		l.state.out = c.output
+	}
+	l.state.unbuffered = c.unbuffered
+	l.state.mu.Unlock()
+
+	l.state.config.Store(&c)
+}
+
+type buildInfo struct {
+	Version  string `json:"version,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Time     string `json:"time,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+}
+
+// WithOutput makes the logger write to w instead of os.Stderr. Writes that
+// are short or fail with a transient error (like EINTR or a timeout) are
+// retried a few times with a short backoff, other errors like a closed file
+// go to the error handler (see WithErrorHandler) right away.
+func WithOutput(w io.Writer) Option {
+	return func(c *config) {
+		c.output = w
+	}
+}
+
+// WithMinSeverity drops the entries with a lower severity than min, e.g.
+// WithMinSeverity(InfoSeverity) drops the DEBUG and DEFAULT entries.
+func WithMinSeverity(min Severity) Option {
+	return func(c *config) {
+		c.minSeverity = min
+	}
+}
+
+func (c *config) enabled(severity Severity) bool {
+	return severity.Number() >= c.minSeverity.Number()
+}
+
+// Enabled tells if entries with the severity are written with the current
+// config, so costly fields can be skipped when they wouldn't be logged:
+//
+//	if log.Enabled(runlogger.DebugSeverity) {
+//		log.Debug("state", log.Field("state", expensiveDump()))
+//	}
+//
+// An enabled entry can still be dropped later, e.g. by a hook, by Once or
+// by the success sampling of the Middleware.
+func (l *Logger) Enabled(severity Severity) bool {
+	return l.conf().enabled(severity)
+}
+
+// WithUnbuffered flushes the output after every entry if it has a Flush
+// method, e.g. a bufio.Writer given to WithOutput, so every entry is visible
+// right away when tailing the logs. The logger doesn't buffer anything
+// itself, so this only matters for buffered outputs.
+func WithUnbuffered() Option {
+	return func(c *config) {
+		c.unbuffered = true
+	}
+}
+
+// WithBuildInfo adds a "build" field to every entry with the module version
+// and the vcs revision and time the binary was built from. Values that
+// aren't available (e.g. when using `go run`) are left out, the vcs values
+// need Go 1.18 or newer.
+func WithBuildInfo() Option {
+	return func(c *config) {
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		var info buildInfo
+		if bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		info.addVCS(bi)
+		if info != (buildInfo{}) {
+			c.setFields(&Field{"build", info})
+		}
+	}
+}
+
+// WithSchemaVersion adds a "schema_version" field with version to every
+// entry, in every format, so the consumers of the logs can tell the
+// versions of their layout apart. Setting it again replaces the version.
+func WithSchemaVersion(version string) Option {
+	return func(c *config) {
+		fields := []*Field{{"schema_version", version}}
+		for _, field := range c.fields {
+			if field.Key != "schema_version" {
+				fields = append(fields, field)
+			}
+		}
+		c.fields = fields
+	}
+}
+
+// WithHook registers a function that gets every entry before it's written.
+// Returning false drops the entry, a returned (non nil) entry is written
+// instead of the original. Hooks run in the order they were registered.
+func WithHook(hook func(*Entry) (*Entry, bool)) Option {
+	return func(c *config) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+// WithMaxBytes sets how many bytes a Bytes field keeps, the default is 1024.
+// A negative n is the same as 0.
+func WithMaxBytes(n int) Option {
+	return func(c *config) {
+		if n < 0 {
+			n = 0
+		}
+		c.maxBytes = n
+	}
+}
+
+// WithMaxPayloadBytes limits the size of the jsonPayload of the structured
+// logger to about n bytes. When it's bigger the largest fields are replaced
+// with a "[trimmed N bytes]" text, one at a time until it fits, so the
+// message and the small fields are kept.
+func WithMaxPayloadBytes(n int) Option {
+	return func(c *config) {
+		c.maxPayloadBytes = n
+	}
+}
+
+// WithMaxFields keeps only the first n fields of an entry, the number of
+// fields that were dropped is in a "fields_truncated" field.
+func WithMaxFields(n int) Option {
+	return func(c *config) {
+		c.maxFields = n
+	}
+}
+
+// trimPayload replaces the largest values of the payload until it's at most
+// max bytes as JSON
+func (l *Logger) trimPayload(payload map[string]interface{}, max int) {
+	l.budgetRaw(payload, max)
+	sizes := make(map[string]int, len(payload))
+	keys := make([]string, 0, len(payload))
+	total := 2 // {}
+	for key, value := range payload {
+		j, _ := l.conf().marshal(value)
+		sizes[key] = len(j)
+		keys = append(keys, key)
+		total += len(key) + len(j) + 4 // "":,
+	}
+	// largest first, by key when they're the same size to be deterministic
+	sort.Slice(keys, func(i, j int) bool {
+		if sizes[keys[i]] != sizes[keys[j]] {
+			return sizes[keys[i]] > sizes[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		if total <= max {
+			return
+		}
+		marker := fmt.Sprintf("[trimmed %d bytes]", sizes[key])
+		payload[key] = marker
+		total -= sizes[key] - len(marker) - 2
+	}
+}
+
+// WithSourceLocationFor makes only the entries with one of the given
+// severities include the source location, by default all of them do.
+// Without any severities no entries get a source location, which also saves
+// the cost of looking it up.
+func WithSourceLocationFor(severities ...Severity) Option {
+	return func(c *config) {
+		c.sourceLocationFor = map[Severity]bool{}
+		for _, severity := range severities {
+			c.sourceLocationFor[severity] = true
+		}
+	}
+}
+
+// WithSourceLocationKey makes the structured logger write the source
+// location with key instead of "logging.googleapis.com/sourceLocation",
+// for sinks other than Cloud Logging.
+func WithSourceLocationKey(key string) Option {
+	return func(c *config) {
+		c.sourceLocationKey = key
+	}
+}
+
+func (c *config) withSourceLocation(severity Severity) bool {
+	return (c.sourceLocationFor == nil || c.sourceLocationFor[severity]) && !c.strips(severity, SourceLocationMetadata)
+}
+
+// Metadata is a part of the LogEntry besides the message and the payload,
+// see WithoutMetadata.
+type Metadata int
+
+const (
+	SourceLocationMetadata Metadata = iota // logging.googleapis.com/sourceLocation
+	ServiceContextMetadata                 // serviceContext
+)
+
+// WithoutMetadata leaves the metadata out of the entries with the
+// severity, whatever the other options say, e.g. to keep the EMERGENCY
+// entries that page someone short:
+//
+//	runlogger.WithoutMetadata(runlogger.EmergencySeverity, runlogger.SourceLocationMetadata, runlogger.ServiceContextMetadata)
+//
+// Error Reporting groups the errors by the serviceContext and where they
+// were logged, so errors without them are grouped by the message only. The
+// entries are still sent to Error Reporting.
+func WithoutMetadata(severity Severity, metadata ...Metadata) Option {
+	return func(c *config) {
+		stripped := map[Severity]map[Metadata]bool{}
+		for s, m := range c.withoutMetadata {
+			stripped[s] = m
+		}
+		m := map[Metadata]bool{}
+		for key := range stripped[severity] {
+			m[key] = true
+		}
+		for _, key := range metadata {
+			m[key] = true
+		}
+		stripped[severity] = m
+		c.withoutMetadata = stripped
+	}
+}
+
+func (c *config) strips(severity Severity, metadata Metadata) bool {
+	return c.withoutMetadata[severity][metadata]
+}
+
+// WithMarshaler makes the structured logger marshal entries with marshal
+// instead of json.Marshal, e.g. with a faster drop-in replacement like
+// jsoniter.ConfigCompatibleWithStandardLibrary.Marshal. It has to honor the
+// encoding/json struct tags.
+func WithMarshaler(marshal func(interface{}) ([]byte, error)) Option {
+	return func(c *config) {
+		c.marshal = marshal
+	}
+}
+
+// WithMessagePrefix puts prefix and a space in front of every message, e.g.
+// "[billing]" to tell services apart in a stream shared by several.
+func WithMessagePrefix(prefix string) Option {
+	return func(c *config) {
+		c.messagePrefix = prefix
+	}
+}
+
+// WithCallerFrames makes entries include up to n frames of the call stack,
+// starting at the log call, in "sourceLocations". The first frame is also
+// the source location of the entry, like without this option. The default
+// is 1, which leaves out sourceLocations.
+func WithCallerFrames(n int) Option {
+	return func(c *config) {
+		c.callerFrames = n
+	}
+}
+
+// WithNumericSeverity makes the structured logger write the severity as the
+// number of the LogSeverity enum (see Severity.Number) instead of as text.
+func WithNumericSeverity(numeric bool) Option {
+	return func(c *config) {
+		c.numericSeverity = numeric
+	}
+}
+
+func (c *config) severityValue(severity Severity) interface{} {
+	if c.numericSeverity {
+		return severity.Number()
+	}
+	return severity
+}
+
+// WithClock makes the logger get the timestamp of entries from now instead
+// of time.Now. Together with a fixed source location (or none, see
+// WithSourceLocationFor) it makes the output the same byte for byte for the
+// same log calls, e.g. for snapshot tests. The output is deterministic
+// otherwise: jsonPayload and labels are sorted by key, and when several
+// fields have the same key the last one wins.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
+
+// sequence is the last sequence number given to an entry
+var sequence uint64
+
+// WithSequenceNumbers adds a "seq" field to every entry with a number that
+// increases by one for every entry in the process, to tell the order of
+// entries with the same timestamp.
+func WithSequenceNumbers() Option {
+	return func(c *config) {
+		c.sequenceNumbers = true
+	}
+}
+
+// WithOmitNilFields leaves out fields whose value is nil, also typed nils
+// like a nil *User, instead of writing them as null.
+func WithOmitNilFields(omit bool) Option {
+	return func(c *config) {
+		c.omitNilFields = omit
+	}
+}
+
+func omitNilFields(fields []*Field) []*Field {
+	var kept []*Field
+	for _, field := range fields {
+		if !isNil(field.Value) {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// WithUTC makes the timestamps of the entries UTC (the default), or in the
+// local time zone with utc false. Either way the timestamp has the offset,
+// "Z" for UTC.
+func WithUTC(utc bool) Option {
+	return func(c *config) {
+		c.utc = utc
+	}
+}
+
+// ServiceContextMode tells which entries get the serviceContext, see
+// WithServiceContext.
+type ServiceContextMode int
+
+const (
+	ServiceContextOnErrors ServiceContextMode = iota // only ERROR and above, which Error Reporting groups by it
+	ServiceContextAlways
+	ServiceContextNever
+)
+
+// WithServiceContext sets which entries the structured logger adds the
+// serviceContext (the K_SERVICE of Cloud Run) to. By default only the
+// entries that go to Error Reporting get it, to keep the other entries
+// small.
+func WithServiceContext(mode ServiceContextMode) Option {
+	return func(c *config) {
+		c.serviceContext = mode
+	}
+}
+
+// WithDropEmptyMessages drops the entries that have neither a message nor
+// any fields, like the ones from an accidental log.Info(). Fields added to
+// every entry (like with WithBuildInfo) don't count.
+func WithDropEmptyMessages(drop bool) Option {
+	return func(c *config) {
+		c.dropEmptyMessages = drop
+	}
+}
+
+// WithErrorHandler makes the logger call handle when logging fails: when an
+// entry can't be formatted, when writing to the output fails and when a
+// subscriber (see Subscribe) is too slow to get an entry. E.g. to count the
+// failures in a metric. By default the error is written to os.Stderr.
+func WithErrorHandler(handle func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handle
+	}
+}
+
+// WithSeverityMapper makes Error, Errorf and Errorw log at the severity
+// severityFor returns for the first error among their arguments, so the
+// errors can be classified in one place:
+//
+//	runlogger.WithSeverityMapper(func(err error) runlogger.Severity {
+//		if errors.Is(err, context.Canceled) {
+//			return runlogger.InfoSeverity
+//		}
+//		return runlogger.ErrorSeverity
+//	})
+//
+// It only applies when an error is among the arguments, the entries without
+// one are always ERROR. By default the entries are ERROR either way.
+func WithSeverityMapper(severityFor func(error) Severity) Option {
+	return func(c *config) {
+		c.severityMapper = severityFor
+	}
+}
+
+// errorSeverity is the severity of the Error methods called with args
+func (c *config) errorSeverity(args []interface{}) Severity {
+	if c.severityMapper == nil {
+		return ErrorSeverity
+	}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			return c.severityMapper(err)
+		}
+	}
+	return ErrorSeverity
+}
+
+func (c *config) handleError(err error) {
+	if c.errorHandler != nil {
+		c.errorHandler(err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
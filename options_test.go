@@ -0,0 +1,204 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestReconfigureConcurrent should be run with -race.
+func TestReconfigureConcurrent(t *testing.T) {
+	l, buf := testLogger()
+	var mu sync.Mutex // guards buf for reading it
+	l.Reopen(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return buf.Write(p)
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			child := l.WithTrace("trace", "")
+			for j := 0; j < 500; j++ {
+				child.Debug("debug")
+				child.Info("info")
+				child.Enabled(DebugSeverity)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		min := InfoSeverity
+		if i%2 == 0 {
+			min = DebugSeverity
+		}
+		l.Reconfigure(WithMinSeverity(min), WithTopLevel("round", i))
+	}
+	wg.Wait()
+
+	l.Reconfigure(WithMinSeverity(InfoSeverity))
+	mu.Lock()
+	buf.Reset()
+	mu.Unlock()
+	l.Debug("dropped")
+	l.Info("kept")
+	if got := buf.String(); strings.Contains(got, "dropped") || !strings.Contains(got, "kept") {
+		t.Errorf("expected only the INFO after the last Reconfigure, got %s", got)
+	}
+	if n := strings.Count(buf.String(), `"round"`); n != 1 {
+		t.Errorf("expected one round key, got %d in %s", n, buf.String())
+	}
+}
+
+func TestReconfigureDoesNotDuplicateFields(t *testing.T) {
+	l, buf := testLogger(WithTopLevel("key", 1), WithHostname())
+	l.Reconfigure(WithTopLevel("key", 2), WithHostname())
+	l.Info("entry")
+	if n := strings.Count(buf.String(), `"key"`); n != 1 {
+		t.Errorf("expected one key, got %d in %s", n, buf.String())
+	}
+	if n := strings.Count(buf.String(), `"hostname"`); n != 1 {
+		t.Errorf("expected one hostname, got %d in %s", n, buf.String())
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestOmitNilFields(t *testing.T) {
+	type user struct{ Name string }
+	var (
+		nilPointer *user
+		nilMap     map[string]int
+		nilSlice   []int
+		nilError   error
+	)
+	fields := func(l *Logger) []interface{} {
+		return []interface{}{
+			l.Field("untyped", nil),
+			l.Field("pointer", nilPointer),
+			l.Field("map", nilMap),
+			l.Field("slice", nilSlice),
+			l.Field("error", nilError),
+			l.Field("kept", &user{"u"}),
+			l.Field("zero", 0),
+		}
+	}
+
+	l, buf := testLogger(WithOmitNilFields(true))
+	l.Info(append([]interface{}{"omitted"}, fields(l)...)...)
+	payload := rawPayload(t, buf)
+	if len(payload) != 2 || payload["kept"] == nil || payload["zero"] == nil {
+		t.Errorf("expected only the non-nil fields, got %v", payload)
+	}
+
+	l, buf = testLogger()
+	l.Info(append([]interface{}{"kept"}, fields(l)...)...)
+	if payload := rawPayload(t, buf); len(payload) != 7 || string(payload["untyped"]) != "null" || string(payload["pointer"]) != "null" {
+		t.Errorf("expected the nil fields by default, got %v", payload)
+	}
+}
+
+func TestServiceContextPerSeverity(t *testing.T) {
+	t.Setenv("K_SERVICE", "app")
+	onErrors := map[Severity]bool{
+		DebugSeverity:     false,
+		InfoSeverity:      false,
+		NoticeSeverity:    false,
+		WarningSeverity:   false,
+		ErrorSeverity:     true,
+		CriticalSeverity:  true,
+		AlertSeverity:     true,
+		EmergencySeverity: true,
+	}
+	for _, test := range []struct {
+		mode ServiceContextMode
+		want func(Severity) bool
+	}{
+		{ServiceContextOnErrors, func(s Severity) bool { return onErrors[s] }},
+		{ServiceContextAlways, func(Severity) bool { return true }},
+		{ServiceContextNever, func(Severity) bool { return false }},
+	} {
+		l, buf := testLogger(WithServiceContext(test.mode))
+		for severity := range onErrors {
+			buf.Reset()
+			l.emit(&Entry{Severity: severity, Message: "entry", Timestamp: l.conf().now()})
+			var entry struct {
+				ServiceContext *ServiceContext `json:"serviceContext"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatal(err)
+			}
+			if got := entry.ServiceContext != nil; got != test.want(severity) {
+				t.Errorf("mode %d, %s: expected serviceContext %t, got %s", test.mode, severity, test.want(severity), buf)
+			}
+			if entry.ServiceContext != nil && entry.ServiceContext.Service != "app" {
+				t.Errorf("expected the service app, got %q", entry.ServiceContext.Service)
+			}
+		}
+	}
+}
+
+func TestMaxPayloadBytesTrimsTheLargestField(t *testing.T) {
+	l, buf := testLogger(WithMaxPayloadBytes(1000))
+	body := strings.Repeat("x", 5000)
+	fields := []interface{}{"request dumped", l.Field("body", body)}
+	small := map[string]interface{}{}
+	for i := 0; i < 10; i++ {
+		key := "small_" + string(rune('a'+i))
+		small[key] = float64(i)
+		fields = append(fields, l.Field(key, i))
+	}
+	l.Info(fields...)
+
+	if !strings.Contains(buf.String(), `"message":"request dumped"`) {
+		t.Errorf("expected the message to be kept, got %s", buf)
+	}
+	payload := decode(t, buf)[0]
+	if want := "[trimmed 5002 bytes]"; payload["body"] != want {
+		t.Errorf("expected the body to be %q, got %v", want, payload["body"])
+	}
+	for key, value := range small {
+		if payload[key] != value {
+			t.Errorf("expected %s to be kept as %v, got %v", key, value, payload[key])
+		}
+	}
+}
+
+func TestMaxFields(t *testing.T) {
+	for _, test := range []struct {
+		fields, max int
+		truncated   interface{}
+	}{
+		{3, 5, nil},
+		{5, 5, nil},
+		{8, 5, float64(3)},
+		{1000, 1, float64(999)},
+	} {
+		l, buf := testLogger(WithMaxFields(test.max))
+		v := []interface{}{"runaway"}
+		for i := 0; i < test.fields; i++ {
+			v = append(v, l.Field(fmt.Sprintf("f%04d", i), i))
+		}
+		l.Info(v...)
+
+		payload := decode(t, buf)[0]
+		if payload["fields_truncated"] != test.truncated {
+			t.Errorf("%d fields, max %d: expected fields_truncated %v, got %v", test.fields, test.max, test.truncated, payload["fields_truncated"])
+		}
+		kept := test.fields
+		if kept > test.max {
+			kept = test.max
+		}
+		for i := 0; i < test.fields; i++ {
+			if _, ok := payload[fmt.Sprintf("f%04d", i)]; ok != (i < kept) {
+				t.Errorf("%d fields, max %d: expected f%04d to be kept %t", test.fields, test.max, i, i < kept)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package runlogger
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// WithRepanic makes Go and the Middleware panic again after logging a
+// panic, so it ends like it would without them. By default the panic is
+// logged, and the goroutine ends or the request is answered with a 500.
+func WithRepanic() Option {
+	return func(c *config) {
+		c.repanic = true
+	}
+}
+
+// Go runs fn in a new goroutine and logs it as a CRITICAL entry, with the
+// stack trace formatted so Error Reporting picks it up, if fn panics.
+func (l *Logger) Go(fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				l.logPanic(r, debug.Stack())
+				if l.conf().repanic {
+					panic(r)
+				}
+			}
+		}()
+		fn()
+	}()
+}
+
+// logPanic logs the recovered value r with the stack, it has to be called
+// from the deferred function that recovered
+func (l *Logger) logPanic(r interface{}, stack []byte, fields ...*Field) {
+	entry := &Entry{
+		Severity: CriticalSeverity,
+		// Error Reporting wants the message to look like a go panic
+		Message:   fmt.Sprintf("panic: %v\n\n%s", r, stack),
+		Fields:    append(fields, &Field{"panic", fmt.Sprint(r)}),
+		Timestamp: l.conf().now(),
+	}
+	if l.conf().withSourceLocation(CriticalSeverity) {
+		if frame, ok := panicFrame(); ok {
+			entry.File = relative(frame.File)
+			entry.Line = frame.Line
+			entry.Function = frame.Function
+		}
+	}
+	l.emit(entry)
+}
+
+// panicFrame finds the frame that panicked, it's the first one after the
+// runtime's panic functions
+func panicFrame() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+	var panicking bool
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			panicking = panicking || frame.Function == "runtime.gopanic"
+		} else if panicking {
+			return frame, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
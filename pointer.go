@@ -0,0 +1,121 @@
+package runlogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+type pointerValue struct {
+	value interface{}
+}
+
+// pointerObject is an object made for the At fields, other maps are values
+// that At fields can't be set inside of
+type pointerObject map[string]interface{}
+
+// At returns a field that is set at the JSON Pointer (RFC 6901) in
+// jsonPayload, the objects on the way are made as needed:
+//
+//	log.Info("request",
+//		log.At("/http/request/method", r.Method),
+//		log.At("/http/request/headers/user_agent", r.UserAgent()),
+//	)
+//
+// gives {"http": {"request": {"method": "GET", "headers": {"user_agent": "..."}}}}.
+// A pointer that is invalid or conflicts with another one, like setting
+// both "/a" to a value and "/a/b", or with another field, like a field "a"
+// and "/a/b", is logged with the pointer as the key
+// instead and a warning is logged the first time it happens for the
+// pointer.
+func (l *Logger) At(pointer string, value interface{}) *Field {
+	return &Field{pointer, pointerValue{value}}
+}
+
+// extractPointers replaces the At fields of the entry with the objects
+// they make up, it returns the warnings to log after the entry is written
+func (l *Logger) extractPointers(entry *Entry) (warnings []string) {
+	var (
+		fields []*Field
+		root   = pointerObject{}
+		keys   []string            // the keys of root in the order they were made
+		taken  = map[string]bool{} // the keys of the other fields
+	)
+	for _, field := range entry.Fields {
+		if _, ok := field.Value.(pointerValue); !ok {
+			taken[field.Key] = true
+		}
+	}
+	if len(taken) == len(entry.Fields) {
+		return nil
+	}
+	for _, field := range entry.Fields {
+		value, ok := field.Value.(pointerValue)
+		if !ok {
+			fields = append(fields, field)
+			continue
+		}
+		parts, ok := parsePointer(field.Key)
+		if ok && taken[parts[0]] {
+			ok = false // it would overwrite the other field, or be overwritten by it
+		}
+		if ok {
+			_, exists := root[parts[0]]
+			ok = root.set(parts, value.value)
+			if ok && !exists {
+				keys = append(keys, parts[0])
+			}
+		}
+		if !ok {
+			fields = append(fields, &Field{field.Key, value.value})
+			if _, warned := l.state.warnedPointers.LoadOrStore(field.Key, true); !warned {
+				warnings = append(warnings, fmt.Sprintf("the field %q (logged in [%s:%d]) isn't a valid JSON Pointer or conflicts with another field, it's logged with the pointer as key instead", field.Key, entry.File, entry.Line))
+			}
+		}
+	}
+	for _, key := range keys {
+		fields = append(fields, &Field{key, root[key]})
+	}
+	entry.Fields = fields
+	return warnings
+}
+
+// set sets the value at the path, it's false if the path goes through a
+// value or if an object is replaced by a value, the objects on the way are
+// only made when it can't fail anymore
+func (o pointerObject) set(path []string, value interface{}) bool {
+	node := o
+	for i, part := range path[:len(path)-1] {
+		child, exists := node[part]
+		if !exists {
+			for _, part := range path[i : len(path)-1] {
+				created := pointerObject{}
+				node[part] = created
+				node = created
+			}
+			break
+		}
+		object, ok := child.(pointerObject)
+		if !ok {
+			return false
+		}
+		node = object
+	}
+	last := path[len(path)-1]
+	if _, isObject := node[last].(pointerObject); isObject {
+		return false
+	}
+	node[last] = value
+	return true
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, bool) {
+	if len(pointer) < 2 || pointer[0] != '/' {
+		return nil, false
+	}
+	parts := strings.Split(pointer[1:], "/")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+	}
+	return parts, true
+}
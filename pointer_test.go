@@ -0,0 +1,74 @@
+package runlogger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAt(t *testing.T) {
+	l, buf := testLogger()
+	l.Info("request",
+		l.At("/http/request/method", "GET"),
+		l.At("/http/request/headers/user_agent", "curl"),
+		l.At("/a~1b", 1),
+	)
+
+	payloads := decode(t, buf)
+	expected := map[string]interface{}{
+		"http": map[string]interface{}{"request": map[string]interface{}{
+			"method":  "GET",
+			"headers": map[string]interface{}{"user_agent": "curl"},
+		}},
+		"a/b": float64(1),
+	}
+	if len(payloads) != 1 || !reflect.DeepEqual(payloads[0], expected) {
+		t.Errorf("expected %v, got %v", expected, payloads)
+	}
+}
+
+func TestAtConflicts(t *testing.T) {
+	for name, fields := range map[string]func(l *Logger) []interface{}{
+		"value then object": func(l *Logger) []interface{} {
+			return []interface{}{l.At("/a", 1), l.At("/a/b", 2)}
+		},
+		"object then value": func(l *Logger) []interface{} {
+			return []interface{}{l.At("/a/b", 2), l.At("/a", 1)}
+		},
+		"field then pointer": func(l *Logger) []interface{} {
+			return []interface{}{l.Field("a", 1), l.At("/a/b", 2)}
+		},
+		"pointer then field": func(l *Logger) []interface{} {
+			return []interface{}{l.At("/a/b", 2), l.Field("a", 1)}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			l, buf := testLogger()
+			l.Info(append([]interface{}{"conflict"}, fields(l)...)...)
+
+			payloads := decode(t, buf)
+			if len(payloads) != 2 {
+				t.Fatalf("expected the entry and a warning, got %v", payloads)
+			}
+			if payloads[0]["a"] == nil || payloads[0]["/a/b"] == nil && payloads[0]["/a"] == nil {
+				t.Errorf("expected both values to be kept, got %v", payloads[0])
+			}
+			if a, ok := payloads[0]["a"].(map[string]interface{}); ok && len(a) == 0 {
+				t.Errorf("expected no empty objects, got %v", payloads[0])
+			}
+			if !strings.Contains(buf.String(), "conflicts with another field") {
+				t.Errorf("expected a warning after the entry, got %s", buf)
+			}
+		})
+	}
+}
+
+func TestAtThroughValueMakesNoObjects(t *testing.T) {
+	o := pointerObject{"a": 1}
+	if o.set([]string{"a", "b", "c"}, 2) {
+		t.Fatal("expected setting through a value to fail")
+	}
+	if !reflect.DeepEqual(o, pointerObject{"a": 1}) {
+		t.Errorf("expected the object to be unchanged, got %v", o)
+	}
+}
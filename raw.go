@@ -0,0 +1,120 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// rawValue is the value of a Raw field, it's called when it's marshaled
+type rawValue func() json.RawMessage
+
+func (v rawValue) MarshalJSON() ([]byte, error) {
+	if j := v(); j != nil {
+		return j, nil
+	}
+	return []byte("null"), nil
+}
+
+// Raw returns a field with the JSON that fn returns, it's only called when
+// the entry is written, so it's not called at all for entries that are
+// dropped (e.g. by WithMinSeverity or a hook), and at most once per entry,
+// even with sinks, taps and subscribers.
+//
+// The structured logger sizes the other fields first, and fn is only called
+// when they leave room in the WithMaxPayloadBytes limit (or in the max size
+// of an entry without a limit), otherwise the field is logged as
+// "[trimmed]". When the raw fields don't all fit, the largest of them are
+// the first to be replaced with a "[trimmed N bytes]" text.
+func (l *Logger) Raw(key string, fn func() json.RawMessage) *Field {
+	return &Field{key, rawValue(fn)}
+}
+
+// entryRawValue is the rawValue of an entry that is being written, it's
+// only called once however many sinks, formatters, taps and subscribers
+// marshal the entry
+type entryRawValue struct {
+	fn   rawValue
+	once sync.Once
+	j    []byte
+}
+
+func (v *entryRawValue) MarshalJSON() ([]byte, error) {
+	v.once.Do(func() {
+		v.j, _ = v.fn.MarshalJSON()
+	})
+	return v.j, nil
+}
+
+// resolveRawOnce returns the fields with the Raw values replaced by ones
+// that are called at most once for the entry, the Raw fields themselves
+// can be used for more entries
+func resolveRawOnce(fields []*Field) []*Field {
+	var resolved []*Field
+	for i, field := range fields {
+		fn, ok := field.Value.(rawValue)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = append([]*Field{}, fields...)
+		}
+		resolved[i] = &Field{field.Key, &entryRawValue{fn: fn}}
+	}
+	if resolved == nil {
+		return fields
+	}
+	return resolved
+}
+
+// budgetRaw calls the functions of the Raw fields in the payload if the
+// other fields leave room for them in max bytes, and then trims the largest
+// of them until the payload fits
+func (l *Logger) budgetRaw(payload map[string]interface{}, max int) {
+	var raw []string
+	for key, value := range payload {
+		if _, ok := value.(*entryRawValue); ok {
+			raw = append(raw, key)
+		}
+	}
+	if len(raw) == 0 {
+		return
+	}
+	total := 2 // {}
+	for key, value := range payload {
+		if _, ok := value.(*entryRawValue); !ok {
+			j, _ := l.conf().marshal(value)
+			total += len(key) + len(j) + 4 // "":,
+		}
+	}
+
+	if total >= max {
+		for _, key := range raw {
+			payload[key] = "[trimmed]" // not called, so the size isn't known
+		}
+		return
+	}
+	sizes := make(map[string]int, len(raw))
+	for _, key := range raw {
+		j, _ := payload[key].(*entryRawValue).MarshalJSON()
+		payload[key] = json.RawMessage(j)
+		sizes[key] = len(j)
+		total += len(key) + len(j) + 4
+	}
+	// largest first, by key when they're the same size to be deterministic
+	sort.Slice(raw, func(i, j int) bool {
+		if sizes[raw[i]] != sizes[raw[j]] {
+			return sizes[raw[i]] > sizes[raw[j]]
+		}
+		return raw[i] < raw[j]
+	})
+	for _, key := range raw {
+		if total <= max {
+			return
+		}
+		marker := fmt.Sprintf("[trimmed %d bytes]", sizes[key])
+		payload[key] = marker
+		total -= sizes[key] - len(marker) - 2
+	}
+}
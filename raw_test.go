@@ -0,0 +1,46 @@
+package runlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRawIsCalledOncePerEntry(t *testing.T) {
+	var sink1, sink2 bytes.Buffer
+	l, buf := testLogger(WithSink(nil, &sink1), WithSink(nil, &sink2))
+	var calls int
+	raw := l.Raw("dump", func() json.RawMessage {
+		calls++
+		return json.RawMessage(`{"big":true}`)
+	})
+
+	l.Info("first", raw)
+	if calls != 1 {
+		t.Errorf("expected one call for the entry, got %d", calls)
+	}
+	l.Info("second", raw)
+	if calls != 2 {
+		t.Errorf("expected one more call for the next entry, got %d", calls)
+	}
+	for _, out := range []*bytes.Buffer{buf, &sink1, &sink2} {
+		if n := strings.Count(out.String(), `"dump":{"big":true}`); n != 2 {
+			t.Errorf("expected the raw field in both entries, got %s", out)
+		}
+	}
+}
+
+func TestRawIsNotCalledWithoutRoom(t *testing.T) {
+	l, buf := testLogger(WithMaxPayloadBytes(100))
+	l.Info("dumped",
+		l.Field("body", strings.Repeat("x", 200)),
+		l.Raw("dump", func() json.RawMessage {
+			t.Error("expected Raw not to be called")
+			return nil
+		}),
+	)
+	if payload := decode(t, buf)[0]; payload["dump"] != "[trimmed]" {
+		t.Errorf("expected the raw field to be trimmed, got %v", payload)
+	}
+}
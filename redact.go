@@ -0,0 +1,38 @@
+package runlogger
+
+import "strings"
+
+// defaultRedactedWords are the words of the names of environment variables
+// and headers that have their values redacted by default
+var defaultRedactedWords = []string{
+	"secret", "password", "passwd", "token", "key", "credential", "credentials",
+	"auth", "authorization", "cookie", "private", "dsn",
+}
+
+// WithRedactedWords adds words to the ones that make LogEnvDiff and the
+// headers logged by the Middleware log "[REDACTED]" instead of the value,
+// e.g. "session" for SESSION_ID. The words are matched whole, and in any
+// case, with the names split into words like SnakeCase does, so "key"
+// matches API_KEY, X-Api-Key and apiKey but not KEYBOARD or MONKEY_ID. By
+// default the words are secret, password, passwd, token, key, credential,
+// credentials, auth, authorization, cookie, private and dsn.
+func WithRedactedWords(words ...string) Option {
+	return func(c *config) {
+		for _, word := range words {
+			c.redactedWords = append(c.redactedWords, strings.ToLower(word))
+		}
+	}
+}
+
+// redacts tells if the value of the environment variable or header name is
+// redacted
+func (c *config) redacts(name string) bool {
+	for _, word := range strings.Split(SnakeCase(name), "_") {
+		for _, redacted := range c.redactedWords {
+			if word == redacted {
+				return true
+			}
+		}
+	}
+	return false
+}
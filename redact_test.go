@@ -0,0 +1,89 @@
+package runlogger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedacts(t *testing.T) {
+	c := newConfig(nil)
+	for name, want := range map[string]bool{
+		"DB_PASSWORD":          true,
+		"GITHUB_TOKEN":         true,
+		"API_KEY":              true,
+		"apiKey":               true,
+		"X-Api-Key":            true,
+		"Authorization":        true,
+		"Proxy-Authorization":  true,
+		"Set-Cookie":           true,
+		"GOOGLE_CREDENTIALS":   true,
+		"KEYBOARD_LAYOUT":      false,
+		"MONKEY_ID":            false,
+		"AUTHOR":               false,
+		"TOKENIZER_MODEL":      false,
+		"PORT":                 false,
+		"SESSION_ID":           false,
+		"X-Forwarded-For":      false,
+		"User-Agent":           false,
+		"DATABASE_DSN":         true,
+		"private.key.filename": true,
+	} {
+		if got := c.redacts(name); got != want {
+			t.Errorf("redacts(%q) = %t, expected %t", name, got, want)
+		}
+	}
+
+	WithRedactedWords("Session")(c)
+	if !c.redacts("SESSION_ID") {
+		t.Error("expected an added word to be redacted")
+	}
+}
+
+func TestRedactedWordsAreShared(t *testing.T) {
+	l, buf := testLogger(WithRedactedWords("session"))
+	l.LogEnvDiff(nil, map[string]string{"SESSION_ID": "s3cr3t", "KEYBOARD": "dvorak"})
+
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Session", "s3cr3t")
+	}), WithLogResponseHeaders("X-Session"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if strings.Contains(buf.String(), "s3cr3t") {
+		t.Errorf("expected the session values to be redacted, got %s", buf)
+	}
+	if !strings.Contains(buf.String(), "dvorak") {
+		t.Errorf("expected KEYBOARD to be logged, got %s", buf)
+	}
+}
+
+type AccountID string
+
+func TestInfoTaggedRedacts(t *testing.T) {
+	type signup struct {
+		AccountID
+		Email    string `log:"email"`
+		Password string
+		APIKey   string `log:"api"`
+		Session  string
+	}
+	l, buf := testLogger(WithRedactedWords("session"))
+	l.InfoTagged("signup", signup{"a1", "ola@example.com", "hunter2", "k3y", ""})
+	got := decode(t, buf)[0]
+	want := map[string]interface{}{
+		"AccountID": "a1",
+		"email":     "ola@example.com",
+		"Password":  "[REDACTED]",
+		"api":       "[REDACTED]",
+		"Session":   "",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("expected %s to be %q, got %s", key, value, buf)
+		}
+	}
+	if _, ok := got["value"]; ok {
+		t.Errorf("expected the embedded AccountID under its type name, got %s", buf)
+	}
+}
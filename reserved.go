@@ -0,0 +1,105 @@
+package runlogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reservedKeys are the keys with a special meaning in a LogEntry
+var reservedKeys = map[string]bool{
+	"message":        true,
+	"severity":       true,
+	"timestamp":      true,
+	"@type":          true,
+	"httpRequest":    true,
+	"serviceContext": true,
+}
+
+// isReservedKey tells if the key is reserved in the LogEntry, the key of
+// the source location set with WithSourceLocationKey is too
+func (c *config) isReservedKey(key string) bool {
+	return reservedKeys[key] || strings.HasPrefix(key, "logging.googleapis.com/") || key == c.sourceLocationKey && key != ""
+}
+
+// WithReservedKeyFormat sets how a field with a key that is reserved in the
+// LogEntry (message, severity, timestamp, @type, httpRequest, serviceContext,
+// logging.googleapis.com/... and the key of WithSourceLocationKey) is
+// renamed. The format gets the key as
+// %s, the default is "_%s_" which e.g. logs a "message" field as "_message_".
+// A format that doesn't have exactly one %s, and no other verbs, is ignored.
+func WithReservedKeyFormat(format string) Option {
+	return func(c *config) {
+		if strings.Count(format, "%s") == 1 && strings.Count(format, "%") == 1 {
+			c.reservedKeyFormat = format
+		}
+	}
+}
+
+// WithReservedKeySuffix renames a field with a reserved key (see
+// WithReservedKeyFormat) by adding suffix, e.g. "_" logs a "message" field
+// as "message_".
+func WithReservedKeySuffix(suffix string) Option {
+	return WithReservedKeyFormat("%s" + strings.ReplaceAll(suffix, "%", ""))
+}
+
+// WithMessageKey renames a "message" field to key, whatever the format of
+// the other reserved keys is, e.g. to "msg".
+func WithMessageKey(key string) Option {
+	return func(c *config) {
+		c.messageKey = key
+	}
+}
+
+// WithReservedKeyWarning logs a warning the first time a field with a
+// reserved key is renamed, so it's easy to find out why a key changed.
+func WithReservedKeyWarning() Option {
+	return func(c *config) {
+		c.reservedKeyWarning = true
+	}
+}
+
+func (l *Logger) renameReservedKey(key string) string {
+	c := l.conf()
+	if key == "message" && c.messageKey != "" {
+		return c.messageKey
+	}
+	return strings.Replace(c.reservedKeyFormat, "%s", key, 1)
+}
+
+// reservedKeyWarnings returns the warnings for the fields of the entry that
+// are renamed the first time, they are logged after the entry like the
+// other warnings about it
+func (l *Logger) reservedKeyWarnings(entry *Entry) (warnings []string) {
+	c := l.conf()
+	if !c.reservedKeyWarning || !l.formatsStackdriver() {
+		return nil
+	}
+	var location string
+	if entry.File != "" {
+		location = fmt.Sprintf(" (logged in [%s:%d])", entry.File, entry.Line)
+	}
+	for _, field := range entry.Fields {
+		if !c.isReservedKey(field.Key) {
+			continue
+		}
+		if _, warned := l.state.warnedKeys.LoadOrStore(field.Key, true); !warned {
+			warnings = append(warnings, fmt.Sprintf("the field %q%s is reserved and was logged as %q instead", field.Key, location, l.renameReservedKey(field.Key)))
+		}
+	}
+	return warnings
+}
+
+// formatsStackdriver tells if the entries are formatted for stackdriver,
+// by the output or a sink, which is where reserved keys are renamed
+func (l *Logger) formatsStackdriver() bool {
+	c := l.conf()
+	if c.formatter == nil && !l.plain {
+		return true
+	}
+	for _, s := range c.sinks {
+		if s.formatter == nil {
+			return true
+		}
+	}
+	return false
+}
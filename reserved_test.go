@@ -0,0 +1,47 @@
+package runlogger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReservedKeyRename(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, "_message_"},
+		{"format", []Option{WithReservedKeyFormat("user.%s")}, "user.message"},
+		{"format without verb", []Option{WithReservedKeyFormat("user")}, "_message_"},
+		{"format with other verbs", []Option{WithReservedKeyFormat("%s%d")}, "_message_"},
+		{"suffix", []Option{WithReservedKeySuffix("_")}, "message_"},
+		{"message key", []Option{WithReservedKeySuffix("_"), WithMessageKey("msg")}, "msg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := testLogger(tt.opts...)
+			l.Info("entry", l.Field("message", "field"))
+			if got := decode(t, buf)[0][tt.want]; got != "field" {
+				t.Errorf("expected the field as %q, got %s", tt.want, buf)
+			}
+		})
+	}
+}
+
+func TestReservedKeyWarningAfterEntry(t *testing.T) {
+	l, buf := testLogger(WithReservedKeyWarning())
+	l.Info("entry", l.Field("severity", "field"))
+	l.Info("again", l.Field("severity", "field"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected the entries and one warning, got %s", buf)
+	}
+	if !strings.Contains(lines[0], `"message":"entry"`) || !strings.Contains(lines[2], `"message":"again"`) {
+		t.Errorf("expected the warning after the entry, got %s", buf)
+	}
+	want := `the field \"severity\" is reserved and was logged as \"_severity_\" instead`
+	if !strings.Contains(lines[1], want) {
+		t.Errorf("expected the warning %s without a source location, got %s", want, lines[1])
+	}
+}
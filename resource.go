@@ -0,0 +1,66 @@
+package runlogger
+
+import "os"
+
+// Resource is a monitored resource, the thing the entries are about, see
+// WithResource. Use CloudRunResource or GCEResource to get the label names
+// right.
+type Resource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// CloudRunResource returns the cloud_run_revision resource of a revision of
+// the Cloud Run service, config is the name of its configuration (which is
+// the name of the service, unless it was set up otherwise). On Cloud Run
+// they are in the K_SERVICE, K_REVISION and K_CONFIGURATION environment
+// variables. The project_id is GOOGLE_CLOUD_PROJECT if it's set.
+func CloudRunResource(service, revision, location, config string) Resource {
+	return Resource{
+		Type: "cloud_run_revision",
+		Labels: resourceLabels(map[string]string{
+			"service_name":       service,
+			"revision_name":      revision,
+			"location":           location,
+			"configuration_name": config,
+		}),
+	}
+}
+
+// GCEResource returns the gce_instance resource of the Compute Engine
+// instance with the (numeric) id in the zone, e.g. "europe-north1-a". The
+// project_id is GOOGLE_CLOUD_PROJECT if it's set.
+func GCEResource(instanceID, zone string) Resource {
+	return Resource{
+		Type: "gce_instance",
+		Labels: resourceLabels(map[string]string{
+			"instance_id": instanceID,
+			"zone":        zone,
+		}),
+	}
+}
+
+// resourceLabels adds the project_id and leaves out the empty labels
+func resourceLabels(labels map[string]string) map[string]string {
+	labels["project_id"] = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	for key, value := range labels {
+		if value == "" {
+			delete(labels, key)
+		}
+	}
+	return labels
+}
+
+// WithResource writes the resource at the root of every entry of the
+// structured logger, like a TopLevel field, for agents and sinks that take
+// the resource from the entry:
+//
+//	runlogger.WithResource(runlogger.CloudRunResource(
+//		os.Getenv("K_SERVICE"), os.Getenv("K_REVISION"), "europe-north1", os.Getenv("K_CONFIGURATION"),
+//	))
+//
+// The logging agent of Cloud Run sets the resource itself, so it's not
+// needed there when the entries are written to stdout or stderr.
+func WithResource(resource Resource) Option {
+	return WithTopLevel("resource", resource)
+}
@@ -0,0 +1,27 @@
+package runlogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetryAttempt logs the outcome of an attempt at something that is
+// retried, with "attempt", "max", "error" and "next_delay" fields. A failed
+// attempt is logged as a WARNING, or as an ERROR when it was the last one
+// (attempt >= max), and a successful one (err == nil) as INFO.
+func (l *Logger) RetryAttempt(attempt, max int, err error, nextDelay time.Duration) {
+	fields := []*Field{
+		{"attempt", attempt},
+		{"max", max},
+	}
+	switch {
+	case err == nil:
+		l.writeLog(InfoSeverity, fmt.Sprintf("attempt %d/%d succeeded", attempt, max), fields)
+	case attempt >= max:
+		fields = append(fields, &Field{"error", err.Error()})
+		l.writeLog(ErrorSeverity, fmt.Sprintf("attempt %d/%d failed, giving up: %v", attempt, max, err), fields)
+	default:
+		fields = append(fields, &Field{"error", err.Error()}, &Field{"next_delay", nextDelay.String()})
+		l.writeLog(WarningSeverity, fmt.Sprintf("attempt %d/%d failed, retrying in %s: %v", attempt, max, nextDelay, err), fields)
+	}
+}
@@ -0,0 +1,63 @@
+package runloggertest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/karl-gustav/runlogger"
+	"github.com/karl-gustav/runlogger/runloggertest"
+)
+
+func ExampleRecorder_Find() {
+	rec := runloggertest.NewRecorder(false)
+	log := runlogger.StructuredLogger(rec.Option())
+
+	log.Info("user logged in", log.Field("user_id", 42))
+	log.Error("payment failed", log.Field("user_id", 42), log.Field("error", errors.New("card declined")))
+
+	// an ERROR for user 42
+	entry, ok := rec.Find(func(e *runlogger.Entry) bool {
+		return e.Severity == runlogger.ErrorSeverity && runloggertest.HasField("user_id", 42)(e)
+	})
+	fmt.Println(ok, entry.Message)
+
+	// nothing was logged about user 7
+	_, ok = rec.Find(runloggertest.HasField("user_id", 7))
+	fmt.Println(ok)
+	// Output:
+	// true payment failed
+	// false
+}
+
+func ExampleRecorder_Count() {
+	rec := runloggertest.NewRecorder(false)
+	log := runlogger.StructuredLogger(rec.Option())
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		log.Warning("fetching failed, retrying", log.Field("retry", true), log.Field("attempt", attempt))
+	}
+	log.Info("fetched")
+
+	fmt.Println(rec.Count(runloggertest.HasField("retry", true)))
+	fmt.Println(rec.Count(func(e *runlogger.Entry) bool {
+		return strings.HasPrefix(e.Message, "fetch")
+	}))
+	// Output:
+	// 3
+	// 4
+}
+
+func ExampleHasField() {
+	rec := runloggertest.NewRecorder(false)
+	log := runlogger.StructuredLogger(rec.Option())
+
+	log.Info("order placed", log.Field("items", 2))
+
+	// the types must match, 2 is an int
+	fmt.Println(rec.Count(runloggertest.HasField("items", 2)))
+	fmt.Println(rec.Count(runloggertest.HasField("items", int64(2))))
+	// Output:
+	// 1
+	// 0
+}
@@ -0,0 +1,139 @@
+// Package runloggertest has helpers for testing code that logs with
+// runlogger.
+package runloggertest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/karl-gustav/runlogger"
+)
+
+// Recorder records the entries of a logger:
+//
+//	rec := runloggertest.NewRecorder(false)
+//	log := runlogger.StructuredLogger(rec.Option())
+//
+// Pass the Option of a Recorder last so it sees the entries as changed by
+// any other hooks.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []*runlogger.Entry
+	write   bool
+}
+
+// NewRecorder returns a Recorder, set write to also have the entries
+// written as usual.
+func NewRecorder(write bool) *Recorder {
+	return &Recorder{write: write}
+}
+
+// Option returns the option that makes a logger record to r.
+func (r *Recorder) Option() runlogger.Option {
+	return runlogger.WithHook(func(entry *runlogger.Entry) (*runlogger.Entry, bool) {
+		r.mu.Lock()
+		r.entries = append(r.entries, entry)
+		r.mu.Unlock()
+		return entry, r.write
+	})
+}
+
+// Entries returns a copy of the recorded entries.
+func (r *Recorder) Entries() []*runlogger.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*runlogger.Entry{}, r.entries...)
+}
+
+// Reset forgets the recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+// Find returns the first recorded entry that pred returns true for, e.g.
+// an ERROR with user_id 42:
+//
+//	entry, ok := rec.Find(func(e *runlogger.Entry) bool {
+//		return e.Severity == runlogger.ErrorSeverity && runloggertest.HasField("user_id", 42)(e)
+//	})
+func (r *Recorder) Find(pred func(*runlogger.Entry) bool) (*runlogger.Entry, bool) {
+	for _, entry := range r.Entries() {
+		if pred(entry) {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// Count returns how many of the recorded entries pred returns true for:
+//
+//	if n := rec.Count(runloggertest.HasField("retry", true)); n != 3 {
+//		t.Errorf("expected 3 retries, got %d", n)
+//	}
+func (r *Recorder) Count(pred func(*runlogger.Entry) bool) int {
+	var n int
+	for _, entry := range r.Entries() {
+		if pred(entry) {
+			n++
+		}
+	}
+	return n
+}
+
+// HasField returns a predicate for Find and Count that is true for the
+// entries with a field key that is equal to value (by reflect.DeepEqual, so
+// the types must match too).
+func HasField(key string, value interface{}) func(*runlogger.Entry) bool {
+	return func(entry *runlogger.Entry) bool {
+		for _, field := range entry.Fields {
+			if field.Key == key && reflect.DeepEqual(field.Value, value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AssertEmpty fails the test if anything was logged.
+func (r *Recorder) AssertEmpty(t testing.TB) {
+	t.Helper()
+	if entries := r.Entries(); len(entries) > 0 {
+		t.Errorf("expected no log entries, got %d:\n%s", len(entries), format(entries))
+	}
+}
+
+// AssertSeverity fails the test unless exactly one entry with the severity
+// and a message containing substring was logged.
+func (r *Recorder) AssertSeverity(t testing.TB, severity runlogger.Severity, substring string) {
+	t.Helper()
+	entries := r.Entries()
+	var matches int
+	for _, entry := range entries {
+		if entry.Severity == severity && strings.Contains(entry.Message, substring) {
+			matches++
+		}
+	}
+	if matches != 1 {
+		t.Errorf("expected one %s entry containing %q, got %d in:\n%s", severity, substring, matches, format(entries))
+	}
+}
+
+func format(entries []*runlogger.Entry) string {
+	if len(entries) == 0 {
+		return "\t(no entries)"
+	}
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "\t%s %s:%d: %s", entry.Severity, entry.File, entry.Line, entry.Message)
+		for _, field := range entry.Fields {
+			fmt.Fprintf(&b, " %s=%v", field.Key, field.Value)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+//go:build go1.21
+
+package runlogger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sort"
+	"time"
+)
+
+type slogHandler struct {
+	l      *Logger
+	opts   slog.HandlerOptions
+	attrs  []groupedAttr // from WithAttrs
+	groups []string      // from WithGroup
+}
+
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// Handler returns a slog.Handler that writes the records with this logger.
+// The attributes of a record end up as fields, and groups as nested objects.
+//
+// opts.ReplaceAttr is honored like in the slog handlers, it's also called
+// for the built-in slog.TimeKey, slog.LevelKey and slog.MessageKey. A
+// built-in attribute that is renamed is logged as a field instead, e.g. to
+// keep the message out of the "message" key.
+func (l *Logger) Handler(opts *slog.HandlerOptions) slog.Handler {
+	h := &slogHandler{l: l}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel && h.l.Enabled(severityForLevel(level))
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := *h
+	child.attrs = append([]groupedAttr{}, h.attrs...)
+	for _, attr := range attrs {
+		child.attrs = append(child.attrs, groupedAttr{h.groups, attr})
+	}
+	return &child
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.groups = append(append([]string{}, h.groups...), name)
+	return &child
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	entry := &Entry{
+		Severity:  severityForLevel(r.Level),
+		Message:   r.Message,
+		Timestamp: r.Time,
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		entry.File = relative(frame.File)
+		entry.Line = frame.Line
+		entry.Function = frame.Function
+	}
+
+	payload := map[string]interface{}{}
+	if h.opts.ReplaceAttr != nil {
+		h.replaceBuiltins(entry, r, payload)
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = h.l.conf().now()
+	}
+	for _, a := range h.attrs {
+		h.addAttr(payload, a.groups, a.attr)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		h.addAttr(payload, h.groups, attr)
+		return true
+	})
+
+	keys := make([]string, 0, len(payload))
+	for key := range payload {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry.Fields = append(entry.Fields, &Field{key, payload[key]})
+	}
+
+	h.l.emit(entry)
+	return nil
+}
+
+// replaceBuiltins runs ReplaceAttr on the time, level and message, a
+// built-in that keeps its key updates the entry and one that gets a new key
+// is put in the payload instead
+func (h *slogHandler) replaceBuiltins(entry *Entry, r slog.Record, payload map[string]interface{}) {
+	if !r.Time.IsZero() {
+		entry.Timestamp = time.Time{}
+		a := h.opts.ReplaceAttr(nil, slog.Time(slog.TimeKey, r.Time))
+		if a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime {
+			entry.Timestamp = a.Value.Time()
+		} else if a.Key != "" {
+			payload[a.Key] = attrValue(a.Value)
+		}
+	}
+
+	a := h.opts.ReplaceAttr(nil, slog.Any(slog.LevelKey, r.Level))
+	if level, ok := a.Value.Any().(slog.Level); ok && a.Key == slog.LevelKey {
+		entry.Severity = severityForLevel(level)
+	} else if a.Key != "" {
+		payload[a.Key] = attrValue(a.Value)
+	}
+
+	entry.Message = ""
+	a = h.opts.ReplaceAttr(nil, slog.String(slog.MessageKey, r.Message))
+	if a.Key == slog.MessageKey {
+		entry.Message = a.Value.String()
+	} else if a.Key != "" {
+		payload[a.Key] = attrValue(a.Value)
+	}
+}
+
+func (h *slogHandler) addAttr(payload map[string]interface{}, groups []string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			groups = append(append([]string{}, groups...), attr.Key)
+		}
+		for _, a := range attr.Value.Group() {
+			h.addAttr(payload, groups, a)
+		}
+		return
+	}
+	if h.opts.ReplaceAttr != nil {
+		attr = h.opts.ReplaceAttr(groups, attr)
+		attr.Value = attr.Value.Resolve()
+	}
+	if attr.Key == "" {
+		return
+	}
+
+	for _, group := range groups {
+		nested, ok := payload[group].(map[string]interface{})
+		if !ok {
+			nested = map[string]interface{}{}
+			payload[group] = nested
+		}
+		payload = nested
+	}
+	payload[attr.Key] = attrValue(attr.Value)
+}
+
+func attrValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindGroup:
+		group := map[string]interface{}{}
+		for _, a := range v.Group() {
+			group[a.Key] = attrValue(a.Value.Resolve())
+		}
+		return group
+	}
+	if err, ok := v.Any().(error); ok {
+		return err.Error()
+	}
+	return v.Any()
+}
+
+func severityForLevel(level slog.Level) Severity {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugSeverity
+	case level < slog.LevelWarn:
+		return InfoSeverity
+	case level < slog.LevelError:
+		return WarningSeverity
+	case level < slog.LevelError+4:
+		return ErrorSeverity
+	case level < slog.LevelError+8:
+		return CriticalSeverity
+	case level < slog.LevelError+12:
+		return AlertSeverity
+	default:
+		return EmergencySeverity
+	}
+}
@@ -0,0 +1,143 @@
+package runlogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config is a snapshot of the effective configuration of a logger, see
+// Logger.Config. The options that take a func are only reported as set or
+// not.
+type Config struct {
+	Structured bool
+
+	MinSeverity Severity // "" means every severity is logged
+
+	SourceLocationFor []Severity              // nil means every severity
+	SourceLocationKey string                  // "" means logging.googleapis.com/sourceLocation
+	CallerFrames      int                     // see WithCallerFrames
+	WithoutMetadata   map[Severity][]Metadata // see WithoutMetadata
+
+	Fields []Field // added to every entry, like with WithBuildInfo
+
+	Formatter string   // the type of the formatter, "" for the default
+	Sinks     []string // the types of the formatters of the sinks, "" for the default
+
+	MaxBytes        int
+	MaxPayloadBytes int
+	MaxFields       int
+
+	MessagePrefix     string
+	ReservedKeyFormat string
+	MessageKey        string
+	ContextKeys       []string
+	RedactedWords     []string
+
+	AlertDowngradeMax    int // 0 means off, see WithAlertDowngrade
+	AlertDowngradeWindow time.Duration
+	AlertDowngradeQuiet  time.Duration
+
+	ServiceContext ServiceContextMode
+
+	Unbuffered            bool
+	Repanic               bool
+	NumericSeverity       bool
+	SequenceNumbers       bool
+	CloseSummary          bool
+	OmitNilFields         bool
+	ContextErrors         bool
+	EscalateContextErrors bool
+	UTC                   bool
+	DropEmptyMessages     bool
+	ReservedKeyWarning    bool
+	RedactIPs             bool
+
+	Hooks          int
+	EmitCallbacks  int
+	KeyTransform   bool
+	EntryStats     bool
+	ErrorHandler   bool
+	SeverityMapper bool
+	GeoIP          bool
+}
+
+// Config returns a snapshot of the configuration of the logger, e.g. for a
+// debug endpoint. It's a copy, changing it doesn't change the logger, use
+// Reconfigure for that. The values of the fields aren't copied.
+func (l *Logger) Config() Config {
+	c := l.conf()
+	snapshot := Config{
+		Structured:            l != nil && !l.plain,
+		MinSeverity:           c.minSeverity,
+		SourceLocationKey:     c.sourceLocationKey,
+		CallerFrames:          c.callerFrames,
+		Formatter:             typeName(c.formatter),
+		MaxBytes:              c.maxBytes,
+		MaxPayloadBytes:       c.maxPayloadBytes,
+		MaxFields:             c.maxFields,
+		MessagePrefix:         c.messagePrefix,
+		ReservedKeyFormat:     c.reservedKeyFormat,
+		MessageKey:            c.messageKey,
+		ServiceContext:        c.serviceContext,
+		Unbuffered:            c.unbuffered,
+		Repanic:               c.repanic,
+		NumericSeverity:       c.numericSeverity,
+		SequenceNumbers:       c.sequenceNumbers,
+		CloseSummary:          c.closeSummary,
+		OmitNilFields:         c.omitNilFields,
+		ContextErrors:         c.contextErrors != nil,
+		EscalateContextErrors: c.contextErrors != nil && *c.contextErrors,
+		UTC:                   c.utc,
+		DropEmptyMessages:     c.dropEmptyMessages,
+		ReservedKeyWarning:    c.reservedKeyWarning,
+		RedactIPs:             c.redactIPs,
+		RedactedWords:         append([]string{}, c.redactedWords...),
+		Hooks:                 len(c.hooks),
+		EmitCallbacks:         len(c.emitCallbacks),
+		KeyTransform:          c.keyTransform != nil,
+		EntryStats:            c.entryStats != nil,
+		ErrorHandler:          c.errorHandler != nil,
+		SeverityMapper:        c.severityMapper != nil,
+		GeoIP:                 c.geoResolver != nil,
+	}
+	if c.sourceLocationFor != nil {
+		snapshot.SourceLocationFor = []Severity{}
+		for _, severity := range severities {
+			if c.sourceLocationFor[severity] {
+				snapshot.SourceLocationFor = append(snapshot.SourceLocationFor, severity)
+			}
+		}
+	}
+	if len(c.withoutMetadata) > 0 {
+		snapshot.WithoutMetadata = map[Severity][]Metadata{}
+		for severity, stripped := range c.withoutMetadata {
+			for _, metadata := range []Metadata{SourceLocationMetadata, ServiceContextMetadata} {
+				if stripped[metadata] {
+					snapshot.WithoutMetadata[severity] = append(snapshot.WithoutMetadata[severity], metadata)
+				}
+			}
+		}
+	}
+	for _, field := range c.fields {
+		snapshot.Fields = append(snapshot.Fields, *field)
+	}
+	for _, s := range c.sinks {
+		snapshot.Sinks = append(snapshot.Sinks, typeName(s.formatter))
+	}
+	for _, key := range c.contextKeys {
+		snapshot.ContextKeys = append(snapshot.ContextKeys, key.name)
+	}
+	if storm := c.alertStorm; storm != nil {
+		snapshot.AlertDowngradeMax = storm.max
+		snapshot.AlertDowngradeWindow = storm.window
+		snapshot.AlertDowngradeQuiet = storm.quiet
+	}
+	return snapshot
+}
+
+func typeName(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,41 @@
+package runlogger
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+type sqlValue struct {
+	Query string   `json:"query"`
+	Args  []string `json:"args,omitempty"`
+	Hash  string   `json:"hash"`
+}
+
+// SQL returns a "sql" field with the query, the types of the args and a hash
+// of the query to group on. The values of the args are never logged since
+// they often have personal data in them, use a separate field for the ones
+// that are safe to log.
+//
+//	log.Debug("loading user", log.SQL("SELECT * FROM users WHERE email = $1", email))
+//
+// gives {"sql": {"query": "SELECT ...", "args": ["string"], "hash": "..."}}.
+func (l *Logger) SQL(query string, args ...interface{}) *Field {
+	value := sqlValue{Query: query, Hash: queryHash(query)}
+	for _, arg := range args {
+		if arg == nil {
+			value.Args = append(value.Args, "nil")
+			continue
+		}
+		value.Args = append(value.Args, fmt.Sprintf("%T", arg))
+	}
+	return &Field{"sql", value}
+}
+
+// queryHash is the FNV-1a hash of the query with the whitespace collapsed, so
+// the same query formatted differently gets the same hash
+func queryHash(query string) string {
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(strings.Fields(query), " ")))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -0,0 +1,198 @@
+package runlogger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// state is shared between a logger and the loggers derived from it
+type state struct {
+	config      atomic.Value // *config, swapped by Reconfigure
+	reconfigure sync.Mutex   // serializes Reconfigure
+
+	counts map[Severity]*uint64 // never written to after creation, only the counters are
+
+	warnedLabels sync.Map // label keys that have been warned about
+	warnedKeys   sync.Map // reserved keys that have been warned about
+
+	warnedPointers sync.Map // At pointers that have been warned about
+
+	subscribers subscribers
+
+	alertStorm alertStormState
+
+	started   time.Time // when the logger was created, for the close summary
+	closeOnce sync.Once
+
+	histogramsMu sync.Mutex
+	histograms   []*Histogram // one per name, flushed by Close
+
+	mu         sync.Mutex // guards out and the write failures
+	out        io.Writer
+	unbuffered bool
+
+	writeFailures int       // failed writes in a row
+	retryAt       time.Time // entries are dropped until then after maxWriteFailures
+	dropped       int       // entries lost since the writes started failing
+}
+
+// maxWriteFailures is how many writes in a row can fail before the entries
+// are dropped for a while, so a broken output doesn't slow down every log
+// call. The time doubles for every failed retry, up to maxWriteBackoff.
+const (
+	maxWriteFailures = 3
+	maxWriteBackoff  = time.Minute
+)
+
+func newState(c *config) *state {
+	s := &state{
+		counts:     map[Severity]*uint64{},
+		out:        c.output,
+		unbuffered: c.unbuffered,
+	}
+	s.config.Store(c)
+	s.started = c.now()
+	for _, severity := range severities {
+		s.counts[severity] = new(uint64)
+	}
+	return s
+}
+
+// writeLine writes b and a newline to the output of the logger
+func (l *Logger) writeLine(b []byte) {
+	line := make([]byte, 0, len(b)+1)
+	line = append(append(line, b...), '\n')
+	s := defaultState
+	if l != nil {
+		s = l.state
+	}
+	dropped, err := s.write(line, l.conf().now())
+	if err != nil {
+		l.conf().handleError(err)
+	}
+	if dropped > 0 {
+		l.Warningf("dropped %d entries because writing to the output failed", dropped)
+	}
+}
+
+// write writes the line to the output, it returns the number of entries
+// that were dropped before when the output starts working again. now is
+// the time of the configured clock, for the backoff.
+func (s *state) write(line []byte, now time.Time) (dropped int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Before(s.retryAt) {
+		s.dropped++
+		return 0, nil
+	}
+	if err := writeRetrying(s.out, line); err != nil {
+		s.writeFailures++
+		s.dropped++
+		if s.writeFailures >= maxWriteFailures {
+			backoff := time.Second << (s.writeFailures - maxWriteFailures)
+			if backoff > maxWriteBackoff || backoff <= 0 {
+				backoff = maxWriteBackoff
+			}
+			s.retryAt = now.Add(backoff)
+			return 0, fmt.Errorf("runlogger: %d writes in a row failed, dropping entries for %s: %w", s.writeFailures, backoff, err)
+		}
+		return 0, fmt.Errorf("runlogger: writing an entry failed: %w", err)
+	}
+	if f, ok := s.out.(flusher); ok && s.unbuffered {
+		f.Flush()
+	}
+	dropped = s.dropped
+	s.writeFailures = 0
+	s.retryAt = time.Time{}
+	s.dropped = 0
+	return dropped, nil
+}
+
+// maxWriteAttempts is how many times writeRetrying tries to write a line
+// when the errors are transient, with writeRetryDelay after the first
+// attempt that doubles for every attempt
+const (
+	maxWriteAttempts = 3
+	writeRetryDelay  = time.Millisecond
+)
+
+// writeRetrying writes line to w, the rest of it is written again after a
+// short write or a transient error (like EINTR), a permanent error (like a
+// closed file), or an error after all of it was written, is returned right
+// away
+func writeRetrying(w io.Writer, line []byte) error {
+	delay := writeRetryDelay
+	for attempt := 1; ; attempt++ {
+		n, err := w.Write(line)
+		if err == nil && n == len(line) {
+			return nil
+		}
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+		if n >= len(line) || !transient(err) {
+			return err // all of it was written, retrying would duplicate it
+		}
+		if attempt == maxWriteAttempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+		if n > 0 {
+			line = line[n:]
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// transient tells if a write that failed with err may work if it's tried
+// again
+func transient(err error) bool {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, io.ErrShortWrite), errors.Is(err, syscall.EINTR), errors.Is(err, syscall.EAGAIN):
+		return true
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	}
+	return false
+}
+
+// defaultState is used by nil loggers
+var defaultState = newState(defaultConfig)
+
+type flusher interface {
+	Flush() error
+}
+
+// Reopen flushes the current output, if it has a Flush method (like a
+// bufio.Writer), and makes the logger and the loggers derived from it write
+// to w instead. Entries are written either before or after the switch,
+// never lost or split between the two, so an external log rotation can
+// reopen the log file on SIGHUP:
+//
+//	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+//	...
+//	err = log.Reopen(f)
+//
+// If flushing fails the output isn't switched. A nil logger always writes
+// to os.Stderr and can't be reopened.
+func (l *Logger) Reopen(w io.Writer) error {
+	if l == nil {
+		return errors.New("runlogger: can't reopen a nil logger")
+	}
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+	if f, ok := l.state.out.(flusher); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	l.state.out = w
+	return nil
+}
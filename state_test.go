@@ -0,0 +1,152 @@
+package runlogger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestReopenConcurrentWrites should be run with -race.
+func TestReopenConcurrentWrites(t *testing.T) {
+	const writers, entries, reopens = 8, 200, 50
+
+	outputs := []*bytes.Buffer{{}}
+	first := bufio.NewWriterSize(outputs[0], 64) // flushed by Reopen
+	l, _ := testLogger(WithOutput(first))
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < entries; j++ {
+				l.Info("entry", l.Field("writer", i), l.Field("n", j))
+			}
+		}(i)
+	}
+	for i := 0; i < reopens; i++ {
+		out := &bytes.Buffer{}
+		outputs = append(outputs, out)
+		if err := l.Reopen(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	var total int
+	for _, out := range outputs {
+		for _, line := range bytes.Split(out.Bytes(), []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal(line, &entry); err != nil {
+				t.Fatalf("entry split between outputs: %q", line)
+			}
+			total++
+		}
+	}
+	if total != writers*entries {
+		t.Errorf("expected %d entries, got %d", writers*entries, total)
+	}
+}
+
+func TestWriteFailuresThenRecovery(t *testing.T) {
+	const failures = maxWriteFailures + 2
+
+	var buf bytes.Buffer
+	var calls int
+	out := writerFunc(func(p []byte) (int, error) {
+		calls++
+		if calls <= maxWriteFailures {
+			return 0, os.ErrClosed // permanent, so it isn't retried
+		}
+		return buf.Write(p)
+	})
+	now := time.Date(2021, 9, 27, 10, 14, 7, 0, time.UTC)
+	var errs []error
+	l, _ := testLogger(
+		WithOutput(out),
+		WithClock(func() time.Time { return now }),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }),
+	)
+
+	for i := 0; i < failures; i++ {
+		l.Info("lost")
+	}
+	if calls != maxWriteFailures {
+		t.Fatalf("expected %d writes before backing off, got %d", maxWriteFailures, calls)
+	}
+	if len(errs) != maxWriteFailures || !errors.Is(errs[len(errs)-1], os.ErrClosed) {
+		t.Fatalf("expected %d errors wrapping os.ErrClosed, got %v", maxWriteFailures, errs)
+	}
+
+	now = now.Add(time.Second) // the first backoff is over
+	l.Info("recovered")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected the entry and the dropped warning, got %q", lines)
+	}
+	var warning struct {
+		Severity string
+		Message  string
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &warning); err != nil {
+		t.Fatal(err)
+	}
+	if warning.Severity != "WARNING" || !strings.Contains(warning.Message, "dropped 5 entries") {
+		t.Errorf("unexpected warning: %s", lines[1])
+	}
+}
+
+func TestWriteRetrying(t *testing.T) {
+	line := []byte("0123456789\n")
+	type write struct {
+		n   int // -1 writes the rest
+		err error
+	}
+	tests := []struct {
+		name    string
+		writes  []write
+		want    string
+		wantErr error
+	}{
+		{"EINTR after all of it", []write{{len(line), syscall.EINTR}}, string(line), syscall.EINTR},
+		{"short writes", []write{{4, nil}, {3, syscall.EAGAIN}, {-1, nil}}, string(line), nil},
+		{"permanent error", []write{{2, os.ErrClosed}}, "01", os.ErrClosed},
+		{"gives up", []write{{0, syscall.EINTR}, {0, syscall.EINTR}, {0, syscall.EINTR}}, "", syscall.EINTR},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var calls int
+			w := writerFunc(func(p []byte) (int, error) {
+				write := test.writes[calls]
+				calls++
+				if write.n < 0 {
+					write.n = len(p)
+				}
+				buf.Write(p[:write.n])
+				return write.n, write.err
+			})
+			err := writeRetrying(w, line)
+			if !errors.Is(err, test.wantErr) || (err == nil) != (test.wantErr == nil) {
+				t.Errorf("expected the error %v, got %v", test.wantErr, err)
+			}
+			if buf.String() != test.want {
+				t.Errorf("expected %q to be written, got %q", test.want, buf.String())
+			}
+			if calls != len(test.writes) {
+				t.Errorf("expected %d writes, got %d", len(test.writes), calls)
+			}
+		})
+	}
+}
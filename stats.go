@@ -0,0 +1,119 @@
+package runlogger
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+var severities = []Severity{
+	DefaultSeverity,
+	DebugSeverity,
+	InfoSeverity,
+	NoticeSeverity,
+	WarningSeverity,
+	ErrorSeverity,
+	CriticalSeverity,
+	AlertSeverity,
+	EmergencySeverity,
+}
+
+func (s *state) emitted(severity Severity) {
+	if counter, ok := s.counts[severity]; ok {
+		atomic.AddUint64(counter, 1)
+	}
+}
+
+// Stats returns how many entries have been written per severity by this
+// logger and the loggers derived from it. Entries dropped by a hook aren't
+// counted. A nil logger doesn't keep stats and returns nil.
+func (l *Logger) Stats() map[Severity]uint64 {
+	if l == nil {
+		return nil
+	}
+	stats := make(map[Severity]uint64, len(l.state.counts))
+	for severity, counter := range l.state.counts {
+		stats[severity] = atomic.LoadUint64(counter)
+	}
+	return stats
+}
+
+// WithEmitCallback registers a function that is called with the severity of
+// every entry that is written, e.g. to feed a metric.
+func WithEmitCallback(callback func(Severity)) Option {
+	return func(c *config) {
+		c.emitCallbacks = append(c.emitCallbacks, callback)
+	}
+}
+
+// EntryStats is what it cost to write an entry, see WithEntryStats.
+type EntryStats struct {
+	Severity   Severity
+	Bytes      int    // the size of the formatted entry
+	Allocs     uint64 // heap allocations
+	AllocBytes uint64 // bytes allocated on the heap
+}
+
+// WithEntryStats calls report with the size of every entry and the heap
+// allocations made while it was written, to find out what logging costs
+// before optimizing. The allocations are counted for the whole process, so
+// they're only exact when nothing else runs at the same time, like in a
+// benchmark. Reading the counters stops the world (runtime.ReadMemStats),
+// so this is for debugging only.
+func WithEntryStats(report func(EntryStats)) Option {
+	return func(c *config) {
+		c.entryStats = report
+	}
+}
+
+// allocMeasurement measures the allocations between start and done
+type allocMeasurement struct {
+	before runtime.MemStats
+}
+
+func (m *allocMeasurement) start() {
+	runtime.ReadMemStats(&m.before)
+}
+
+func (m *allocMeasurement) done(severity Severity, size int) EntryStats {
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	return EntryStats{
+		Severity:   severity,
+		Bytes:      size,
+		Allocs:     after.Mallocs - m.before.Mallocs,
+		AllocBytes: after.TotalAlloc - m.before.TotalAlloc,
+	}
+}
+
+type runtimeStats struct {
+	Alloc       uint64 `json:"alloc"`
+	TotalAlloc  uint64 `json:"total_alloc"`
+	Sys         uint64 `json:"sys"`
+	HeapObjects uint64 `json:"heap_objects"`
+	NumGC       uint32 `json:"num_gc"`
+	PauseTotal  string `json:"pause_total"`
+	Goroutines  int    `json:"goroutines"`
+}
+
+// RuntimeStats logs a DEBUG with the memory use (in bytes), the number of
+// garbage collections and goroutines in a "runtime" field, e.g. to look for
+// a leak. runtime.ReadMemStats stops the world, so the stats are only read
+// when DEBUG is enabled (see WithMinSeverity).
+func (l *Logger) RuntimeStats(fields ...*Field) {
+	if !l.Enabled(DebugSeverity) {
+		return
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	stats := runtimeStats{
+		Alloc:       m.Alloc,
+		TotalAlloc:  m.TotalAlloc,
+		Sys:         m.Sys,
+		HeapObjects: m.HeapObjects,
+		NumGC:       m.NumGC,
+		PauseTotal:  time.Duration(m.PauseTotalNs).String(),
+		Goroutines:  runtime.NumGoroutine(),
+	}
+	l.writeLog(DebugSeverity, "runtime stats", append(fields[:len(fields):len(fields)], &Field{"runtime", stats}))
+}
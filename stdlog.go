@@ -0,0 +1,87 @@
+package runlogger
+
+import (
+	"log"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StdLogger returns a *log.Logger from the standard library that writes
+// every line it gets as an entry with the given severity. Useful for
+// libraries that want a *log.Logger.
+//
+// The writer of the returned logger can also be used as the output of the
+// standard logger, with any flags and prefix:
+//
+//	log.SetOutput(logger.StdLogger(runlogger.InfoSeverity).Writer())
+//
+// The date, time, file and prefix the log package adds to a line are
+// stripped from the message. The source location is where the line was
+// logged, or the file and line of log.Llongfile or log.Lshortfile when a
+// line has them.
+func (l *Logger) StdLogger(severity Severity) *log.Logger {
+	w := &stdWriter{l: l, severity: severity}
+	w.std = log.New(w, "", log.LstdFlags|log.Lmsgprefix)
+	return w.std
+}
+
+type stdWriter struct {
+	l        *Logger
+	severity Severity
+	std      *log.Logger // the logger from StdLogger, for its prefix
+}
+
+// stdHeader matches what the flags of the log package add before the
+// message: the date, the time and the file and line
+var stdHeader = regexp.MustCompile(`^(?:\d{4}/\d{2}/\d{2} )?(?:\d{2}:\d{2}:\d{2}(?:\.\d+)? )?(?:(\S+\.go):(\d+): )?`)
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	caller := stdCaller()
+	prefixes := []string{w.std.Prefix(), log.Prefix()}
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		line = trimPrefixes(line, prefixes) // without log.Lmsgprefix the prefix is first
+		header := stdHeader.FindStringSubmatch(line)
+		message := trimPrefixes(line[len(header[0]):], prefixes)
+
+		entry := &Entry{
+			Severity:  w.severity,
+			Message:   message,
+			Timestamp: w.l.conf().now(),
+		}
+		if w.l.conf().withSourceLocation(w.severity) {
+			entry.File, entry.Line, entry.Function = relative(caller.File), caller.Line, caller.Function
+			if header[1] != "" {
+				line, _ := strconv.Atoi(header[2])
+				if !strings.HasSuffix(caller.File, header[1]) || line != caller.Line {
+					entry.File, entry.Line, entry.Function = relative(header[1]), line, ""
+				}
+			}
+		}
+		w.l.emit(entry)
+	}
+	return len(p), nil
+}
+
+func trimPrefixes(s string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(s, prefix) {
+			return s[len(prefix):]
+		}
+	}
+	return s
+}
+
+// stdCaller returns the first frame outside of the log package and the
+// stdWriter, where the line was logged
+func stdCaller() runtime.Frame {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "log.") || !more {
+			return frame
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package runlogger
+
+import (
+	"sync"
+	"time"
+)
+
+type alertStorm struct {
+	max    int
+	window time.Duration
+	quiet  time.Duration
+}
+
+type alertStormState struct {
+	mu       sync.Mutex
+	recent   []time.Time // the times of the last max alerts
+	tripped  bool
+	lastSeen time.Time
+}
+
+// WithAlertDowngrade keeps a stream of ALERT and EMERGENCY entries from
+// paging people over and over. When max of them have been logged within
+// window the following ones are logged as ERROR, with a "downgraded_from"
+// field telling the original severity, until there has been none for
+// quiet. A max of 0 or less turns it off.
+func WithAlertDowngrade(max int, window, quiet time.Duration) Option {
+	return func(c *config) {
+		if max <= 0 {
+			c.alertStorm = nil
+			return
+		}
+		c.alertStorm = &alertStorm{max, window, quiet}
+	}
+}
+
+// downgrade returns the severity the entry should be logged with
+func (s *alertStormState) downgrade(policy *alertStorm, severity Severity, now time.Time) (Severity, bool) {
+	if severity != AlertSeverity && severity != EmergencySeverity || policy.max <= 0 {
+		return severity, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tripped && now.Sub(s.lastSeen) > policy.quiet {
+		s.tripped = false
+		s.recent = nil
+	}
+	s.lastSeen = now
+	if s.tripped {
+		return ErrorSeverity, true
+	}
+
+	s.recent = append(s.recent, now)
+	if len(s.recent) > policy.max {
+		s.recent = s.recent[1:]
+	}
+	if len(s.recent) == policy.max && len(s.recent) > 0 && now.Sub(s.recent[0]) <= policy.window {
+		s.tripped = true
+	}
+	return severity, false
+}
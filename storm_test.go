@@ -0,0 +1,27 @@
+package runlogger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertDowngrade(t *testing.T) {
+	for _, test := range []struct {
+		max        int
+		downgraded int
+	}{
+		{-1, 0},
+		{0, 0},
+		{1, 4},
+		{3, 2},
+	} {
+		l, buf := testLogger(WithAlertDowngrade(test.max, time.Minute, time.Minute))
+		for i := 0; i < 5; i++ {
+			l.Alert("paging")
+		}
+		if n := strings.Count(buf.String(), `"downgraded_from"`); n != test.downgraded {
+			t.Errorf("max %d: expected %d downgraded alerts, got %d", test.max, test.downgraded, n)
+		}
+	}
+}
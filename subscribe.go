@@ -0,0 +1,84 @@
+package runlogger
+
+import (
+	"fmt"
+	"sync"
+)
+
+type subscriber struct {
+	entries chan *Entry
+	dropped int // entries dropped since the last one that was sent
+}
+
+type subscribers struct {
+	mu   sync.Mutex
+	subs map[*subscriber]bool
+}
+
+// Subscribe returns a channel that gets the entries written by the logger
+// and the loggers derived from it, e.g. to show the last error in a UI,
+// and a func that unsubscribes and closes the channel.
+//
+//	entries, unsubscribe := log.Subscribe(100)
+//	defer unsubscribe()
+//	for entry := range entries {
+//		...
+//	}
+//
+// The channel has room for buf entries. Entries are dropped when it's full,
+// so a slow subscriber doesn't slow down the logging, and a WARNING entry
+// with the number of dropped entries is sent when there's room again.
+func (l *Logger) Subscribe(buf int) (<-chan *Entry, func()) {
+	s := defaultState
+	if l != nil {
+		s = l.state
+	}
+	sub := &subscriber{entries: make(chan *Entry, buf)}
+	s.subscribers.mu.Lock()
+	if s.subscribers.subs == nil {
+		s.subscribers.subs = map[*subscriber]bool{}
+	}
+	s.subscribers.subs[sub] = true
+	s.subscribers.mu.Unlock()
+
+	var once sync.Once
+	return sub.entries, func() {
+		once.Do(func() {
+			s.subscribers.mu.Lock()
+			defer s.subscribers.mu.Unlock()
+			delete(s.subscribers.subs, sub)
+			close(sub.entries)
+		})
+	}
+}
+
+// publish sends the entry to the subscribers that have room for it, it
+// returns the number of subscribers that didn't
+func (s *subscribers) publish(entry *Entry) (dropped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		if sub.dropped > 0 {
+			notice := &Entry{
+				Severity:  WarningSeverity,
+				Message:   fmt.Sprintf("dropped %d entries because the subscriber was too slow", sub.dropped),
+				Timestamp: entry.Timestamp,
+			}
+			select {
+			case sub.entries <- notice:
+				sub.dropped = 0
+			default:
+				sub.dropped++
+				dropped++
+				continue
+			}
+		}
+		select {
+		case sub.entries <- entry:
+		default:
+			sub.dropped++
+			dropped++
+		}
+	}
+	return dropped
+}
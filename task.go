@@ -0,0 +1,43 @@
+package runlogger
+
+import (
+	"context"
+	"errors"
+	"runtime"
+)
+
+// Task wraps fn so its error is logged, with a "task" field and the given
+// fields, before it's returned. Made for errgroup (or anything else that
+// takes a func() error), which only returns the first error:
+//
+//	g.Go(log.Task("fetch-users", fetchUsers, log.Field("batch", n)))
+//
+// Errors are logged as ERROR, except context.Canceled which is logged as a
+// WARNING since it's usually caused by another task failing.
+//
+// The source location of the entry is where Task was called.
+func (l *Logger) Task(name string, fn func() error, fields ...*Field) func() error {
+	pc, file, line, _ := runtime.Caller(1)
+	return func() error {
+		err := fn()
+		if err != nil {
+			severity := ErrorSeverity
+			if errors.Is(err, context.Canceled) {
+				severity = WarningSeverity
+			}
+			entry := &Entry{
+				Severity:  severity,
+				Message:   "task " + name + " failed: " + err.Error(),
+				Fields:    append([]*Field{{"task", name}, {"error", err.Error()}}, fields...),
+				Timestamp: l.conf().now(),
+			}
+			if l.conf().withSourceLocation(severity) {
+				entry.File = relative(file)
+				entry.Line = line
+				entry.Function = runtime.FuncForPC(pc).Name()
+			}
+			l.emit(entry)
+		}
+		return err
+	}
+}
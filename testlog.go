@@ -0,0 +1,43 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TB is the part of testing.TB that TeeTestLog needs, it's here so the
+// package doesn't depend on the testing package.
+type TB interface {
+	Log(args ...interface{})
+}
+
+// TeeTestLog returns a copy of the logger that also sends every entry, in
+// a compact format with its source location, to tb.Log. Nothing changes in the normal output, so pass
+// it to the code under test to see its logs with `go test -v` or when a
+// test fails.
+func (l *Logger) TeeTestLog(tb TB) *Logger {
+	return l.withTap(func(entry *Entry) {
+		writeTestLog(tb, entry)
+	})
+}
+
+func writeTestLog(tb TB, entry *Entry) {
+	var b strings.Builder
+	b.WriteString(string(entry.Severity))
+	if entry.File != "" {
+		fmt.Fprintf(&b, " %s:%d", entry.File, entry.Line)
+	}
+	b.WriteString(": ")
+	b.WriteString(entry.Message)
+	if len(entry.Fields) > 0 {
+		payload := map[string]interface{}{}
+		for _, field := range entry.Fields {
+			payload[field.Key] = field.Value
+		}
+		j, _ := json.Marshal(payload)
+		b.WriteString(" ")
+		b.Write(j)
+	}
+	tb.Log(b.String())
+}
@@ -0,0 +1,55 @@
+package runlogger
+
+import "time"
+
+// Timed logs a DEBUG that the operation started and returns a func that
+// logs that it's done, with the time it took in an "elapsed" field:
+//
+//	defer log.Timed("import", log.Field("file", name))()
+//
+// The fields given to Timed are on both entries, those given to the
+// returned func only on the last one. If one of them is a non-nil error
+// the last entry is an ERROR with the error as text, so it's easy to log
+// the result of the operation too:
+//
+//	done := log.Timed("import")
+//	err := importFile(name)
+//	done(log.Field("error", err))
+func (l *Logger) Timed(name string, fields ...*Field) func(...*Field) {
+	fields = append([]*Field{{"operation", name}}, fields...)
+	l.writeLog(DebugSeverity, name+" started", fields)
+	start := l.conf().now()
+	return func(end ...*Field) {
+		elapsed := l.conf().now().Sub(start)
+		severity := DebugSeverity
+		all := append(append([]*Field{}, fields...), l.Duration("elapsed", elapsed))
+		for _, field := range end {
+			if err, ok := field.Value.(error); ok && err != nil {
+				severity = ErrorSeverity
+				field = &Field{field.Key, err.Error()}
+			}
+			all = append(all, field)
+		}
+		l.writeLog(severity, name+" done after "+elapsed.String(), all)
+	}
+}
+
+type spanValue struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration durationValue `json:"duration"`
+}
+
+// Span logs an INFO for an operation that has already been done, from
+// start to end, with a "span" field:
+//
+//	{"span": {"name": "backfill", "start": "...", "end": "...", "duration": {"string": "1m0s", "nanos": 60000000000}}}
+//
+// Log with a logger bound to the trace (see WithTrace) to see it with the
+// rest of the trace.
+func (l *Logger) Span(name string, start, end time.Time, fields ...*Field) {
+	d := end.Sub(start)
+	fields = append(fields[:len(fields):len(fields)], &Field{"span", spanValue{name, start, end, durationValue{d.String(), d.Nanoseconds()}}})
+	l.writeLog(InfoSeverity, name+" took "+d.String(), fields)
+}
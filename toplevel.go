@@ -0,0 +1,90 @@
+package runlogger
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+type topLevelValue struct {
+	value interface{}
+}
+
+// TopLevel returns a field that is written at the root of the LogEntry
+// instead of in jsonPayload, for LogEntry keys the logger doesn't have
+// support for, e.g.:
+//
+//	log.Info("done", log.TopLevel("logging.googleapis.com/operation", op))
+//
+// Keys the logger writes itself (like "message", "severity",
+// "logging.googleapis.com/trace" or the key of WithSourceLocationKey) can't
+// be overwritten, those fields are put in jsonPayload instead and renamed
+// like reserved keys (see WithReservedKeyFormat and WithReservedKeyWarning). The plain
+// logger writes them like any other field.
+func (l *Logger) TopLevel(key string, value interface{}) *Field {
+	return &Field{key, topLevelValue{value}}
+}
+
+// WithTopLevel adds a TopLevel field to every entry.
+func WithTopLevel(key string, value interface{}) Option {
+	return func(c *config) {
+		c.setFields(&Field{key, topLevelValue{value}})
+	}
+}
+
+// entryKeys are the keys of the LogEntry written by the logger
+var entryKeys = func() map[string]bool {
+	keys := map[string]bool{}
+	t := reflect.TypeOf(stackdriverLogStruct{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		keys[name] = true
+	}
+	return keys
+}()
+
+// extractTopLevel moves the top level fields out of the entry's fields
+func extractTopLevel(entry *Entry, c *config) {
+	var fields []*Field
+	for _, field := range entry.Fields {
+		value, ok := field.Value.(topLevelValue)
+		if !ok {
+			fields = append(fields, field)
+			continue
+		}
+		if entryKeys[field.Key] || c.sourceLocationKey != "" && field.Key == c.sourceLocationKey {
+			fields = append(fields, &Field{field.Key, value.value})
+			continue
+		}
+		if entry.TopLevel == nil {
+			entry.TopLevel = map[string]interface{}{}
+		}
+		entry.TopLevel[field.Key] = value.value
+	}
+	entry.Fields = fields
+}
+
+// addTopLevel adds the top level values to the marshaled j
+func (l *Logger) addTopLevel(j []byte, topLevel map[string]interface{}) ([]byte, error) {
+	if len(topLevel) == 0 {
+		return j, nil
+	}
+	extra, err := l.conf().marshal(topLevel)
+	if err != nil {
+		return nil, err
+	}
+	// a marshaler (see WithMarshaler) might add a newline
+	j, extra = bytes.TrimRight(j, " \t\r\n"), bytes.TrimSpace(extra)
+	if len(j) < 2 || j[len(j)-1] != '}' || len(extra) < 2 || extra[0] != '{' {
+		return nil, errors.New("the marshaler didn't return a JSON object")
+	}
+	if len(extra) == 2 { // {}
+		return j, nil
+	}
+	if len(j) == 2 {
+		return extra, nil
+	}
+	// both are objects, so join them by replacing "}{" with ","
+	return append(append(j[:len(j)-1:len(j)-1], ','), extra[1:]...), nil
+}
@@ -0,0 +1,51 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTopLevelWithMarshalerAddingNewline(t *testing.T) {
+	marshal := func(v interface{}) ([]byte, error) {
+		j, err := json.MarshalIndent(v, "", "  ")
+		return append(j, '\n'), err
+	}
+	l, buf := testLogger(WithMarshaler(marshal), WithTopLevel("logging.googleapis.com/operation", map[string]string{"id": "op"}))
+	l.Info("entry")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf, err)
+	}
+	if _, ok := entry["logging.googleapis.com/operation"]; !ok {
+		t.Errorf("expected the top level key, got %s", buf)
+	}
+}
+
+func TestTopLevelCantOverwriteEntryKeys(t *testing.T) {
+	l, buf := testLogger()
+	l.Info("entry", l.TopLevel("severity", "EMERGENCY"))
+	if !strings.Contains(buf.String(), `"severity":"INFO"`) || !strings.Contains(buf.String(), `"jsonPayload":{"_severity_":"EMERGENCY"}`) {
+		t.Errorf("expected the field in jsonPayload, got %s", buf)
+	}
+}
+
+func TestTopLevelCantOverwriteSourceLocation(t *testing.T) {
+	l, buf := testLogger(WithSourceLocationFor(InfoSeverity), WithSourceLocationKey("src"))
+	l.Info("entry", l.TopLevel("src", "overwritten"), l.TopLevel("logging.googleapis.com/trace", "t"))
+
+	var entry struct {
+		Src         map[string]interface{} `json:"src"`
+		Trace       string                 `json:"logging.googleapis.com/trace"`
+		JSONPayload map[string]interface{} `json:"jsonPayload"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf, err)
+	}
+	if entry.Src["file"] == nil || entry.Trace != "" {
+		t.Errorf("expected the source location and no trace, got %s", buf)
+	}
+	if entry.JSONPayload["_src_"] != "overwritten" || entry.JSONPayload["_logging.googleapis.com/trace_"] != "t" {
+		t.Errorf("expected the fields renamed in jsonPayload, got %s", buf)
+	}
+}
@@ -0,0 +1,114 @@
+package runlogger
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type transport struct {
+	l    *Logger
+	next http.RoundTripper
+}
+
+// Transport returns an http.RoundTripper that logs every request made with
+// it, with an httpRequest like the Middleware, and passes the trace the
+// logger is bound to (see WithTrace) on in the X-Cloud-Trace-Context and
+// traceparent headers, so the downstream service logs with the same trace:
+//
+//	client := &http.Client{Transport: log.WithTrace(trace, span).Transport(nil)}
+//
+// When the context of the request carries a logger (see NewContext), like
+// the requests given to a handler by the Middleware, that logger is used
+// instead:
+//
+//	req, err := http.NewRequestWithContext(r.Context(), "GET", url, nil)
+//
+// The span id of the headers is logged as outgoing_span_id, and the URL is
+// logged without the query and the password. Headers the request already
+// has are kept. A nil next means
+// http.DefaultTransport. Responses with status 5xx are logged as ERROR,
+// 4xx as WARNING and the rest as INFO, and failed requests as ERROR.
+func (l *Logger) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{l, next}
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	l := t.l
+	if fromContext, ok := loggerFromContext(r.Context()); ok {
+		l = fromContext
+	}
+	var outgoingSpanID string
+	if l != nil && l.trace != "" {
+		r = r.Clone(r.Context()) // a RoundTripper must not change the request
+		traceID := l.trace[strings.LastIndex(l.trace, "/")+1:]
+		spanID := rand.Uint64()
+		if r.Header.Get("X-Cloud-Trace-Context") == "" {
+			r.Header.Set("X-Cloud-Trace-Context", fmt.Sprintf("%s/%d;o=1", traceID, spanID))
+			outgoingSpanID = fmt.Sprintf("%016x", spanID)
+		}
+		if r.Header.Get("traceparent") == "" {
+			r.Header.Set("traceparent", fmt.Sprintf("00-%s-%016x-01", traceID, spanID))
+			outgoingSpanID = fmt.Sprintf("%016x", spanID)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(r)
+	latency := time.Since(start)
+
+	requestURL := loggedURL(r.URL)
+	req := &HTTPRequest{
+		RequestMethod: r.Method,
+		RequestURL:    requestURL,
+		UserAgent:     r.UserAgent(),
+		Latency:       fmt.Sprintf("%.9fs", latency.Seconds()),
+		Protocol:      r.Proto,
+	}
+	if r.ContentLength > 0 {
+		req.RequestSize = strconv.FormatInt(r.ContentLength, 10)
+	}
+	entry := &Entry{
+		Severity:  ErrorSeverity,
+		Fields:    []*Field{l.HTTPRequest(req)},
+		Timestamp: start,
+	}
+	if outgoingSpanID != "" {
+		entry.Fields = append(entry.Fields, &Field{"outgoing_span_id", outgoingSpanID})
+	}
+	if err != nil {
+		entry.Message = fmt.Sprintf("%s %s failed: %v", r.Method, requestURL, err)
+		entry.Fields = append(entry.Fields, &Field{"error", err.Error()})
+	} else {
+		req.Status = resp.StatusCode
+		req.Protocol = resp.Proto
+		if resp.ContentLength >= 0 {
+			req.ResponseSize = strconv.FormatInt(resp.ContentLength, 10)
+		}
+		switch {
+		case resp.StatusCode < 400:
+			entry.Severity = InfoSeverity
+		case resp.StatusCode < 500:
+			entry.Severity = WarningSeverity
+		}
+		entry.Message = fmt.Sprintf("%s %s %d", r.Method, requestURL, resp.StatusCode)
+	}
+	l.emit(entry)
+	return resp, err
+}
+
+// loggedURL is the URL without the query and fragment, that often carry
+// tokens and signatures, and with the password redacted
+func loggedURL(u *url.URL) string {
+	logged := *u
+	logged.RawQuery, logged.ForceQuery = "", false
+	logged.Fragment, logged.RawFragment = "", ""
+	return logged.Redacted()
+}
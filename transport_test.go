@@ -0,0 +1,47 @@
+package runlogger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	l, buf := testLogger()
+	client := &http.Client{Transport: l.WithTrace("projects/p/traces/abc", "1").Transport(nil)}
+	u := strings.Replace(server.URL, "http://", "http://user:secret@", 1) + "/users?token=secret#frag"
+	resp, err := client.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	var entry struct {
+		Message     string
+		HTTPRequest HTTPRequest `json:"httpRequest"`
+		JSONPayload struct {
+			OutgoingSpanID string `json:"outgoing_span_id"`
+		} `json:"jsonPayload"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "secret") || strings.Contains(buf.String(), "frag") {
+		t.Errorf("expected the query, fragment and password to be left out, got %s", buf)
+	}
+	wantURL := strings.Replace(server.URL, "http://", "http://user:xxxxx@", 1) + "/users"
+	if entry.HTTPRequest.RequestURL != wantURL {
+		t.Errorf("expected the URL %q, got %q", wantURL, entry.HTTPRequest.RequestURL)
+	}
+	if entry.JSONPayload.OutgoingSpanID == "" || !strings.Contains(traceparent, "-"+entry.JSONPayload.OutgoingSpanID+"-") {
+		t.Errorf("expected the span id %q to be in the traceparent %q", entry.JSONPayload.OutgoingSpanID, traceparent)
+	}
+}
@@ -0,0 +1,37 @@
+package runlogger
+
+import (
+	"fmt"
+	"sort"
+)
+
+// maxValidationErrors is the max number of fields logged by ValidationErrors
+const maxValidationErrors = 50
+
+// ValidationErrors logs a WARNING with the validation errors in m, from the
+// name of a field to what's wrong with it, in a "validation" field. Nothing
+// is logged if m is empty. Only the first 50 fields (sorted by name) are
+// logged, the number of fields that were left out is in a
+// "validation_omitted" field.
+func (l *Logger) ValidationErrors(m map[string]string, fields ...*Field) {
+	if len(m) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields = fields[:len(fields):len(fields)] // so the appends don't write to the caller's array
+	validation := map[string]string{}
+	for _, key := range keys {
+		if len(validation) == maxValidationErrors {
+			fields = append(fields, &Field{"validation_omitted", len(m) - maxValidationErrors})
+			break
+		}
+		validation[key] = m[key]
+	}
+	fields = append(fields, &Field{"validation", validation})
+	l.writeLog(WarningSeverity, fmt.Sprintf("validation failed for %d fields", len(m)), fields)
+}
@@ -0,0 +1,36 @@
+// Package zapfields converts zap fields to runlogger fields, so code that
+// builds zap fields can be reused when moving to runlogger. It lives in its
+// own module so that the runlogger package itself stays free of
+// dependencies.
+package zapfields
+
+import (
+	"sort"
+
+	"github.com/karl-gustav/runlogger"
+	"go.uber.org/zap/zapcore"
+)
+
+// Fields converts the zap fields to runlogger fields, with the same keys
+// and values as zap's JSON encoder would write:
+//
+//	log.Info("user created", zapfields.Fields(zap.String("user", id), zap.Int("age", age)))
+//
+// Objects and arrays (like zap.Object or zap.Strings) become maps and
+// slices, and namespaces (zap.Namespace) nest the fields after them.
+func Fields(fields ...zapcore.Field) []*runlogger.Field {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	converted := make([]*runlogger.Field, 0, len(keys))
+	for _, key := range keys {
+		converted = append(converted, &runlogger.Field{Key: key, Value: enc.Fields[key]})
+	}
+	return converted
+}
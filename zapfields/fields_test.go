@@ -0,0 +1,30 @@
+package zapfields
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/karl-gustav/runlogger"
+	"go.uber.org/zap"
+)
+
+func TestFields(t *testing.T) {
+	got := Fields(
+		zap.String("user", "42"),
+		zap.Int("age", 7),
+		zap.Strings("roles", []string{"admin"}),
+		zap.Namespace("request"),
+		zap.Bool("cached", true),
+	)
+	want := []*runlogger.Field{
+		{Key: "age", Value: int64(7)},
+		{Key: "request", Value: map[string]interface{}{"cached": true}},
+		{Key: "roles", Value: []interface{}{"admin"}},
+		{Key: "user", Value: "42"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		j, _ := json.Marshal(got)
+		t.Errorf("expected the fields zap would write, got %s", j)
+	}
+}
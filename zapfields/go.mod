@@ -0,0 +1,10 @@
+module github.com/karl-gustav/runlogger/zapfields
+
+go 1.19
+
+require (
+	github.com/karl-gustav/runlogger v0.0.0-20261014192117-2a1e5d961ba2
+	go.uber.org/zap v1.28.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
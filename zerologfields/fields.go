@@ -0,0 +1,46 @@
+// Package zerologfields converts zerolog fields to runlogger fields, so code
+// that adds fields to zerolog events can be reused when moving to runlogger.
+// It lives in its own module so that the runlogger package itself stays
+// free of dependencies.
+package zerologfields
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/karl-gustav/runlogger"
+	"github.com/rs/zerolog"
+)
+
+// Fields runs add on a zerolog event and returns the fields it added as
+// runlogger fields, with the values zerolog would write:
+//
+//	addUser := func(e *zerolog.Event) { e.Str("user", id).Int("age", age) }
+//	log.Info("user created", zerologfields.Fields(addUser))
+//
+// Numbers are json.Number so they keep their precision.
+func Fields(add func(e *zerolog.Event)) []*runlogger.Field {
+	var buf bytes.Buffer
+	zl := zerolog.New(&buf)
+	e := zl.Log()
+	add(e)
+	e.Send()
+
+	values := map[string]interface{}{}
+	d := json.NewDecoder(&buf)
+	d.UseNumber()
+	if err := d.Decode(&values); err != nil {
+		return []*runlogger.Field{{Key: "zerolog_error", Value: err.Error()}}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	converted := make([]*runlogger.Field, 0, len(keys))
+	for _, key := range keys {
+		converted = append(converted, &runlogger.Field{Key: key, Value: values[key]})
+	}
+	return converted
+}
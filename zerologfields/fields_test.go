@@ -0,0 +1,26 @@
+package zerologfields
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/karl-gustav/runlogger"
+	"github.com/rs/zerolog"
+)
+
+func TestFields(t *testing.T) {
+	got := Fields(func(e *zerolog.Event) {
+		e.Str("user", "42").Int("age", 7).Uint64("id", 1<<63).Strs("roles", []string{"admin"})
+	})
+	want := []*runlogger.Field{
+		{Key: "age", Value: json.Number("7")},
+		{Key: "id", Value: json.Number("9223372036854775808")},
+		{Key: "roles", Value: []interface{}{"admin"}},
+		{Key: "user", Value: "42"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		j, _ := json.Marshal(got)
+		t.Errorf("expected the fields zerolog would write, got %s", j)
+	}
+}